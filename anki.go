@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"html"
+	"io"
+	"slices"
+	"strings"
+)
+
+// Default Anki card sides: the Estonian word on the front; its key forms
+// and English translations on the back.
+const (
+	defaultAnkiFront = "word"
+	defaultAnkiBack  = "forms,translations"
+)
+
+// ankiRenderer writes Anki-importable notes, one per result, with the
+// columns GUID, Front, Back and Tags. The GUID is derived from the Ekilex
+// word ID, so importing the same word again updates its note rather than
+// adding a duplicate.
+type ankiRenderer struct {
+	comma rune
+	front []string // Field specs, see ankiField
+	back  []string
+}
+
+// newAnkiRenderer parses comma-separated front and back field specs;
+// empty specs select the defaults.
+func newAnkiRenderer(comma rune, front, back string) (ankiRenderer, error) {
+	if front == "" {
+		front = defaultAnkiFront
+	}
+	if back == "" {
+		back = defaultAnkiBack
+	}
+	r := ankiRenderer{comma: comma}
+	var err error
+	if r.front, err = parseAnkiFields(front); err != nil {
+		return r, fmt.Errorf("-anki-front: %w", err)
+	}
+	if r.back, err = parseAnkiFields(back); err != nil {
+		return r, fmt.Errorf("-anki-back: %w", err)
+	}
+	return r, nil
+}
+
+// ankiFieldNames are the field specs besides morph codes such as "SgG".
+var ankiFieldNames = []string{"word", "translations", "pos", "type", "forms"}
+
+func parseAnkiFields(spec string) ([]string, error) {
+	var fields []string
+	for _, f := range strings.Split(spec, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		if _, ok := morphLabels[f]; !ok && !slices.Contains(ankiFieldNames, f) {
+			return nil, fmt.Errorf("unknown field %q (want %s or a morph code such as SgG)", f, strings.Join(ankiFieldNames, ", "))
+		}
+		fields = append(fields, f)
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("no fields given")
+	}
+	return fields, nil
+}
+
+// ankiGUID returns the stable note ID for an Ekilex word.
+func ankiGUID(wordID int64) string {
+	return fmt.Sprintf("sonaveeb-%d", wordID)
+}
+
+// ankiField returns the plain-text value of one field spec:
+//
+//	word          the word
+//	translations  English translations, comma-separated
+//	pos           part of speech
+//	type          inflection types
+//	forms         key forms (nounMorphCodes or verbMorphCodes), e.g. "pank, panga, panka, pankasid/panku"
+//	SgG, ...      the forms for a morph code, comma-separated
+func ankiField(output FormattedOutput, spec string) string {
+	switch spec {
+	case "word":
+		return output.Word
+	case "translations":
+		return strings.Join(output.Translations, ", ")
+	case "pos":
+		return output.PartOfSpeech
+	case "type":
+		return strings.Join(output.InflectionTypes, ", ")
+	case "forms":
+		var forms []string
+		for _, code := range SelectMorphCodes(output.PartOfSpeech == "verb") {
+			if values := output.Forms[code]; len(values) > 0 {
+				forms = append(forms, strings.Join(values, "/"))
+			}
+		}
+		return strings.Join(forms, ", ")
+	}
+	return strings.Join(output.Forms[spec], ", ")
+}
+
+// side renders the fields of one card side as HTML, one field per line.
+func (r ankiRenderer) side(output FormattedOutput, specs []string) string {
+	var parts []string
+	for _, spec := range specs {
+		if v := ankiField(output, spec); v != "" {
+			parts = append(parts, html.EscapeString(v))
+		}
+	}
+	return strings.Join(parts, "<br>")
+}
+
+func (r ankiRenderer) writer(w io.Writer) *csv.Writer {
+	cw := csv.NewWriter(w)
+	cw.Comma = r.comma
+	return cw
+}
+
+// RenderHeader writes the file headers Anki reads on import, telling it
+// the separator and which columns hold the GUID and tags.
+func (r ankiRenderer) RenderHeader(w io.Writer) error {
+	separator := "comma"
+	if r.comma == '\t' {
+		separator = "tab"
+	}
+	_, err := fmt.Fprintf(w, "#separator:%s\n#html:true\n#notetype:Basic\n#guid column:1\n#tags column:4\n#columns:%s\n",
+		separator, strings.Join([]string{"GUID", "Front", "Back", "Tags"}, string(r.comma)))
+	return err
+}
+
+func (r ankiRenderer) Render(w io.Writer, output FormattedOutput) error {
+	tags := []string{"sonaveeb"}
+	if output.PartOfSpeech != "" {
+		tags = append(tags, output.PartOfSpeech)
+	}
+
+	cw := r.writer(w)
+	_ = cw.Write([]string{
+		ankiGUID(output.WordID),
+		r.side(output, r.front),
+		r.side(output, r.back),
+		strings.Join(tags, " "),
+	})
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRun_AnkiCustomFields(t *testing.T) {
+	cfg := Config{
+		Homonym:   2,
+		Format:    formatAnkiTSV,
+		AnkiFront: "translations",
+		AnkiBack:  "word, SgG, PlP, type",
+		NoHeader:  true,
+	}
+	var buf bytes.Buffer
+	if err := run(context.Background(), "pank", cfg, pankFetcher(), &buf); err != nil {
+		t.Fatalf("run() error: %v", err)
+	}
+
+	want := "sonaveeb-2\tbank, bench\tpank<br>panga<br>pankasid, panku<br>22\tsonaveeb noun\n"
+	if buf.String() != want {
+		t.Errorf("got  %q\nwant %q", buf.String(), want)
+	}
+}
+
+func TestAnkiField_VerbKeyForms(t *testing.T) {
+	details := &WordDetails{
+		WordClass: "verb",
+		Paradigms: []Paradigm{{InflectionTypeNr: "28", Forms: []Form{
+			{Value: "tegema", MorphCode: "Sup"},
+			{Value: "tegin", MorphCode: "IndIpfSg1"},
+			{Value: "teha", MorphCode: "Inf"},
+			{Value: "teeb", MorphCode: "IndPrSg3"},
+			{Value: "tehakse", MorphCode: "IndPrIps"},
+			{Value: "tehtud", MorphCode: "PtsPtIps"},
+		}}},
+	}
+	output := FormatOutput("tegema", details, 1, 1, false)
+
+	if got := ankiField(output, "forms"); got != "tegema, teha, teeb, tehtud" {
+		t.Errorf("forms = %q", got)
+	}
+	// Any morph code works, not only the key forms
+	if got := ankiField(output, "IndIpfSg1"); got != "tegin" {
+		t.Errorf("IndIpfSg1 = %q", got)
+	}
+}
+
+func TestAnkiGUID_StableAcrossExports(t *testing.T) {
+	var first, second bytes.Buffer
+	for _, buf := range []*bytes.Buffer{&first, &second} {
+		cfg := Config{Homonym: 1, Format: formatAnkiCSV}
+		if err := run(context.Background(), "pank", cfg, pankFetcher(), buf); err != nil {
+			t.Fatalf("run() error: %v", err)
+		}
+	}
+	if first.String() != second.String() {
+		t.Errorf("exports differ:\n%s\n%s", first.String(), second.String())
+	}
+	if !strings.Contains(first.String(), "\nsonaveeb-1,") {
+		t.Errorf("expected GUID derived from word ID 1:\n%s", first.String())
+	}
+}
+
+func TestParseAnkiFields(t *testing.T) {
+	fields, err := parseAnkiFields(" word ,SgG,,translations")
+	if err != nil {
+		t.Fatalf("parseAnkiFields() error: %v", err)
+	}
+	if strings.Join(fields, "|") != "word|SgG|translations" {
+		t.Errorf("fields = %v", fields)
+	}
+
+	for _, spec := range []string{"word,nosuch", ",", "sgg"} {
+		if _, err := parseAnkiFields(spec); err == nil {
+			t.Errorf("parseAnkiFields(%q) succeeded, want error", spec)
+		}
+	}
+}
+
+func TestRunBatch_AnkiHeaderOnce(t *testing.T) {
+	fetcher := NewDictFetcher("puu", "maja")
+	cfg := Config{Homonym: 1, Format: formatAnkiTSV, Jobs: 2}
+	var out, errOut bytes.Buffer
+	runBatch(context.Background(), []string{"puu", "maja"}, cfg, fetcher, &out, &errOut)
+
+	if n := strings.Count(out.String(), "#separator:tab\n"); n != 1 {
+		t.Errorf("header written %d times:\n%s", n, out.String())
+	}
+	if !strings.HasSuffix(out.String(), "sonaveeb-1\tpuu\tpuu\tsonaveeb noun\nsonaveeb-2\tmaja\tmaja\tsonaveeb noun\n") {
+		t.Errorf("unexpected notes:\n%s", out.String())
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// BatchSummary records the outcome of each word in a batch lookup.
+type BatchSummary struct {
+	Found    []string
+	NotFound []string
+	Failed   []string
+
+	failCode int // Exit code shared by every failure, or exitFailure if they differ
+}
+
+// add records the outcome of looking up word.
+func (s *BatchSummary) add(word string, err error) {
+	switch {
+	case err == nil:
+		s.Found = append(s.Found, word)
+	case isNotFound(err):
+		s.NotFound = append(s.NotFound, word)
+	default:
+		s.Failed = append(s.Failed, word)
+		code := exitCode(err)
+		if len(s.Failed) > 1 && code != s.failCode {
+			code = exitFailure
+		}
+		s.failCode = code
+	}
+}
+
+// Total returns the number of words processed.
+func (s BatchSummary) Total() int {
+	return len(s.Found) + len(s.NotFound) + len(s.Failed)
+}
+
+// ExitCode returns 0 if every word was found and 1 if some were not found.
+// If any lookup failed for another reason, it returns that failure's exit
+// code when all failures agree (e.g. 4 when the API key was rejected for
+// every word), and 3 otherwise.
+func (s BatchSummary) ExitCode() int {
+	switch {
+	case len(s.Failed) > 0:
+		if s.failCode == 0 {
+			return exitFailure
+		}
+		return s.failCode
+	case len(s.NotFound) > 0:
+		return exitNotFound
+	}
+	return exitOK
+}
+
+// Render formats the summary for stderr.
+func (s BatchSummary) Render() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%d words: %d found, %d not found, %d failed\n",
+		s.Total(), len(s.Found), len(s.NotFound), len(s.Failed)))
+	if len(s.NotFound) > 0 {
+		sb.WriteString(fmt.Sprintf("  not found: %s\n", strings.Join(s.NotFound, ", ")))
+	}
+	if len(s.Failed) > 0 {
+		sb.WriteString(fmt.Sprintf("  failed: %s\n", strings.Join(s.Failed, ", ")))
+	}
+	return sb.String()
+}
+
+// openWordList opens a word list file, or stdin for "-".
+func openWordList(path string) (io.ReadCloser, error) {
+	if path == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	return os.Open(path)
+}
+
+// ReadWords reads one word per line, skipping blank lines and # comments.
+func ReadWords(r io.Reader) ([]string, error) {
+	var words []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+	return words, scanner.Err()
+}
+
+// runBatch looks up each word using up to cfg.Jobs concurrent workers,
+// writing results to w in input order and per-word errors to errw (or, with
+// JSON output, to w as error objects). It keeps going after errors and stops
+// early only if ctx is cancelled.
+func runBatch(ctx context.Context, words []string, cfg Config, fetcher Fetcher, w, errw io.Writer) BatchSummary {
+	ctx, cancel := context.WithCancel(ctx)
+
+	jobs := cfg.Jobs
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	// Tabular formats get one column header for the whole batch
+	if renderer, err := NewRenderer(cfg); err == nil && !cfg.Raw && cfg.Template == "" && !cfg.NoHeader {
+		if h, ok := renderer.(headerRenderer); ok {
+			_ = h.RenderHeader(w)
+		}
+	}
+	cfg.NoHeader = true
+
+	// Each word gets its own buffered slot so workers never block on a
+	// slow consumer, and results can be emitted strictly in order.
+	results := make([]chan batchResult, len(words))
+	for i := range results {
+		results[i] = make(chan batchResult, 1)
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for n := 0; n < jobs; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				// Words already handed out are skipped once cancelled
+				if err := ctx.Err(); err != nil {
+					results[i] <- batchResult{err: err}
+					continue
+				}
+				// Buffer each result so a failed lookup never leaves partial output
+				var buf bytes.Buffer
+				err := run(ctx, words[i], cfg, fetcher, &buf)
+				results[i] <- batchResult{output: buf.Bytes(), err: err}
+			}
+		}()
+	}
+	go func() {
+		defer close(indexes)
+		for i := range words {
+			// select picks at random when both cases are ready
+			if ctx.Err() != nil {
+				return
+			}
+			select {
+			case indexes <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	defer wg.Wait()
+	defer cancel()
+
+	var summary BatchSummary
+	wrote := false
+
+	for i, word := range words {
+		var res batchResult
+		select {
+		case res = <-results[i]:
+		case <-ctx.Done():
+			return summary
+		}
+		if errors.Is(res.err, context.Canceled) {
+			return summary
+		}
+
+		summary.add(word, res.err)
+		switch {
+		case res.err != nil && cfg.JSONOutput():
+			// Errors go in the result stream, in input order
+			_ = writeJSONError(w, word, res.err)
+		case res.err == nil:
+			if wrote && cfg.separateResults() {
+				_, _ = fmt.Fprintln(w)
+			}
+			_, _ = w.Write(res.output)
+			wrote = true
+		case isNotFound(res.err):
+			_, _ = fmt.Fprintf(errw, "error: %v\n", res.err)
+		default:
+			_, _ = fmt.Fprintf(errw, "error: %s: %v\n", word, res.err)
+		}
+	}
+
+	return summary
+}
+
+type batchResult struct {
+	output []byte
+	err    error
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// DictFetcher serves canned API responses for a small fake dictionary.
+// Words missing from the dictionary produce an empty search result;
+// words listed in Failing return an error. It is safe for concurrent use.
+type DictFetcher struct {
+	mu      sync.Mutex
+	Words   map[string]int64 // word -> wordId
+	Failing map[string]bool
+	Calls   int
+}
+
+func NewDictFetcher(words ...string) *DictFetcher {
+	d := &DictFetcher{Words: make(map[string]int64), Failing: make(map[string]bool)}
+	for i, w := range words {
+		d.Words[w] = int64(i + 1)
+	}
+	return d
+}
+
+func (d *DictFetcher) wordFor(id int64) string {
+	for w, wid := range d.Words {
+		if wid == id {
+			return w
+		}
+	}
+	return ""
+}
+
+func (d *DictFetcher) Search(ctx context.Context, word string) ([]byte, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.Calls++
+	if d.Failing[word] {
+		return nil, &APIError{Status: "502 Bad Gateway", StatusCode: http.StatusBadGateway}
+	}
+	id, ok := d.Words[word]
+	if !ok {
+		return []byte(`{"words":[]}`), nil
+	}
+	return []byte(fmt.Sprintf(`{"words":[{"wordId":%d,"wordValue":%q,"lang":"est"}]}`, id, word)), nil
+}
+
+func (d *DictFetcher) WordDetails(ctx context.Context, wordID int64) ([]byte, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.Calls++
+	return []byte(`{"wordClass":"noun"}`), nil
+}
+
+func (d *DictFetcher) ParadigmDetails(ctx context.Context, wordID int64) ([]byte, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.Calls++
+	word := d.wordFor(wordID)
+	return []byte(fmt.Sprintf(`[{"inflectionTypeNr":"1","paradigmForms":[{"value":%q,"morphCode":"SgN"}]}]`, word)), nil
+}
+
+func TestReadWords(t *testing.T) {
+	input := "puu\n\n  maja  \n# comment\nkass\n"
+
+	words, err := ReadWords(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadWords() error: %v", err)
+	}
+
+	want := []string{"puu", "maja", "kass"}
+	if strings.Join(words, ",") != strings.Join(want, ",") {
+		t.Errorf("ReadWords() = %v, want %v", words, want)
+	}
+}
+
+func TestRunBatch(t *testing.T) {
+	fetcher := NewDictFetcher("puu", "maja", "kass")
+	fetcher.Failing["kass"] = true
+
+	cfg := Config{Homonym: 1, Quiet: true, All: true}
+	var out, errOut bytes.Buffer
+	summary := runBatch(context.Background(), []string{"puu", "xyzzy", "kass", "maja"}, cfg, fetcher, &out, &errOut)
+
+	if strings.Join(summary.Found, ",") != "puu,maja" {
+		t.Errorf("found = %v, want [puu maja]", summary.Found)
+	}
+	if strings.Join(summary.NotFound, ",") != "xyzzy" {
+		t.Errorf("not found = %v, want [xyzzy]", summary.NotFound)
+	}
+	if strings.Join(summary.Failed, ",") != "kass" {
+		t.Errorf("failed = %v, want [kass]", summary.Failed)
+	}
+
+	if out.String() != "SgN\tpuu\nSgN\tmaja\n" {
+		t.Errorf("unexpected output: %q", out.String())
+	}
+	if !strings.Contains(errOut.String(), "word not found: xyzzy") {
+		t.Errorf("expected not-found error on stderr, got %q", errOut.String())
+	}
+	if !strings.Contains(errOut.String(), "kass: API error") {
+		t.Errorf("expected failure for kass on stderr, got %q", errOut.String())
+	}
+}
+
+func TestRunBatch_JSONErrorsInline(t *testing.T) {
+	fetcher := NewDictFetcher("puu", "maja", "kass")
+	fetcher.Failing["kass"] = true
+
+	cfg := Config{Homonym: 1, Raw: true, Jobs: 2}
+	var out, errOut bytes.Buffer
+	runBatch(context.Background(), []string{"puu", "xyzzy", "kass", "maja"}, cfg, fetcher, &out, &errOut)
+
+	if errOut.Len() != 0 {
+		t.Errorf("expected no per-word errors on stderr, got %q", errOut.String())
+	}
+
+	// The stream holds one JSON value per word, in input order
+	var got []string
+	dec := json.NewDecoder(&out)
+	for dec.More() {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			t.Fatalf("decoding output: %v\n%s", err, out.String())
+		}
+		// A successful lookup is the raw paradigm array
+		if raw[0] == '[' {
+			got = append(got, "ok")
+			continue
+		}
+		var v struct {
+			Error ErrorOutput `json:"error"`
+		}
+		if err := json.Unmarshal(raw, &v); err != nil {
+			t.Fatalf("decoding error object: %v\n%s", err, raw)
+		}
+		got = append(got, v.Error.Word+":"+v.Error.Code)
+	}
+	want := "ok,xyzzy:word_not_found,kass:api_error,ok"
+	if strings.Join(got, ",") != want {
+		t.Errorf("stream = %v, want %s", got, want)
+	}
+}
+
+func TestRunBatch_StopsOnCancel(t *testing.T) {
+	fetcher := NewDictFetcher("puu", "maja")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var out, errOut bytes.Buffer
+	summary := runBatch(ctx, []string{"puu", "maja"}, Config{Homonym: 1}, fetcher, &out, &errOut)
+
+	if summary.Total() != 0 {
+		t.Errorf("expected no words processed after cancel, got %d", summary.Total())
+	}
+	if fetcher.Calls != 0 {
+		t.Errorf("expected no upstream calls, got %d", fetcher.Calls)
+	}
+}
+
+func TestBatchSummary_ExitCode(t *testing.T) {
+	unauthorized := &APIError{Status: "401 Unauthorized", StatusCode: http.StatusUnauthorized}
+	networkDown := &NetworkError{Err: errors.New("connection refused")}
+
+	tests := []struct {
+		name     string
+		outcomes map[string]error
+		want     int
+	}{
+		{"all found", map[string]error{"puu": nil}, 0},
+		{"empty", nil, 0},
+		{"some not found", map[string]error{"puu": nil, "x": ErrWordNotFound}, 1},
+		{"some failed", map[string]error{"x": ErrWordNotFound, "y": errors.New("boom")}, 3},
+		{"every failure unauthorized", map[string]error{"puu": unauthorized, "maja": unauthorized, "x": ErrWordNotFound}, 4},
+		{"network down", map[string]error{"puu": networkDown}, 6},
+		{"mixed failures", map[string]error{"puu": unauthorized, "maja": networkDown}, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var summary BatchSummary
+			for word, err := range tt.outcomes {
+				summary.add(word, err)
+			}
+			if got := summary.ExitCode(); got != tt.want {
+				t.Errorf("ExitCode() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBatchSummary_Render(t *testing.T) {
+	summary := BatchSummary{
+		Found:    []string{"puu", "maja"},
+		NotFound: []string{"xyzzy"},
+	}
+
+	got := summary.Render()
+	want := "3 words: 2 found, 1 not found, 0 failed\n  not found: xyzzy\n"
+	if got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
+
+// slowFetcher delays searches so that later words finish before earlier ones,
+// and tracks the peak number of concurrent searches.
+type slowFetcher struct {
+	Fetcher
+	delays map[string]time.Duration
+
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+}
+
+func (f *slowFetcher) Search(ctx context.Context, word string) ([]byte, error) {
+	f.mu.Lock()
+	f.inFlight++
+	if f.inFlight > f.peak {
+		f.peak = f.inFlight
+	}
+	f.mu.Unlock()
+
+	defer func() {
+		f.mu.Lock()
+		f.inFlight--
+		f.mu.Unlock()
+	}()
+
+	if err := sleepContext(ctx, f.delays[word]); err != nil {
+		return nil, err
+	}
+	return f.Fetcher.Search(ctx, word)
+}
+
+func TestRunBatch_ConcurrentKeepsOrder(t *testing.T) {
+	words := []string{"puu", "maja", "kass", "koer"}
+	fetcher := &slowFetcher{
+		Fetcher: NewDictFetcher(words...),
+		delays: map[string]time.Duration{
+			"puu":  60 * time.Millisecond,
+			"maja": 40 * time.Millisecond,
+			"kass": 20 * time.Millisecond,
+		},
+	}
+
+	cfg := Config{Homonym: 1, Quiet: true, All: true, Jobs: 4}
+	var out, errOut bytes.Buffer
+	summary := runBatch(context.Background(), words, cfg, fetcher, &out, &errOut)
+
+	if len(summary.Found) != 4 {
+		t.Fatalf("expected 4 found, got %v (stderr: %s)", summary.Found, errOut.String())
+	}
+	want := "SgN\tpuu\nSgN\tmaja\nSgN\tkass\nSgN\tkoer\n"
+	if out.String() != want {
+		t.Errorf("output not in input order:\ngot  %q\nwant %q", out.String(), want)
+	}
+	if strings.Join(summary.Found, ",") != strings.Join(words, ",") {
+		t.Errorf("summary not in input order: %v", summary.Found)
+	}
+	if fetcher.peak < 2 {
+		t.Errorf("expected concurrent lookups, peak in-flight was %d", fetcher.peak)
+	}
+}
+
+func TestRunBatch_JobsLimitConcurrency(t *testing.T) {
+	words := []string{"a", "b", "c", "d", "e", "f"}
+	delays := make(map[string]time.Duration)
+	for _, w := range words {
+		delays[w] = 10 * time.Millisecond
+	}
+	fetcher := &slowFetcher{Fetcher: NewDictFetcher(words...), delays: delays}
+
+	cfg := Config{Homonym: 1, Quiet: true, Jobs: 2}
+	var out, errOut bytes.Buffer
+	runBatch(context.Background(), words, cfg, fetcher, &out, &errOut)
+
+	if fetcher.peak > 2 {
+		t.Errorf("expected at most 2 concurrent lookups, got %d", fetcher.peak)
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"database/sql"
+	"fmt"
 	"os"
 	"path/filepath"
 	"time"
@@ -9,17 +10,40 @@ import (
 	_ "modernc.org/sqlite"
 )
 
-// Cache provides a simple key-value store backed by SQLite.
+// Cache is the default CacheStore, a key-value store backed by SQLite.
+// If limits are set, least-recently-used entries are evicted on Set.
 type Cache struct {
-	db *sql.DB
+	db     *sql.DB
+	limits CacheLimits
+	now    func() time.Time
+}
+
+// CacheLimits bounds the size of the cache. Zero means unlimited.
+type CacheLimits struct {
+	MaxEntries int
+	MaxBytes   int64 // Total size of all values
 }
 
 // CacheEntry holds a cached value and its metadata.
 type CacheEntry struct {
-	Value     []byte
-	CreatedAt time.Time
+	Value      []byte
+	CreatedAt  time.Time
+	AccessedAt time.Time
 }
 
+// Expired reports whether the entry is older than ttl at time now.
+// A zero or negative ttl never expires.
+func (e *CacheEntry) Expired(ttl time.Duration, now time.Time) bool {
+	return ttl > 0 && now.Sub(e.CreatedAt) > ttl
+}
+
+// cacheDSNParams configures every cache connection so that several
+// sonaveeb-cli processes can share one database: WAL lets readers proceed
+// while another process writes, busy_timeout waits for the write lock
+// instead of failing with "database is locked", and immediate transactions
+// take that lock up front so they cannot deadlock upgrading from a read.
+const cacheDSNParams = "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=synchronous(NORMAL)&_txlock=immediate"
+
 // OpenCache opens or creates a cache at the default location.
 // Returns an error if the cache directory or database cannot be created.
 func OpenCache() (*Cache, error) {
@@ -37,47 +61,39 @@ func OpenCacheAt(path string) (*Cache, error) {
 		return nil, err
 	}
 
-	db, err := sql.Open("sqlite", path)
+	db, err := sql.Open("sqlite", path+cacheDSNParams)
 	if err != nil {
 		return nil, err
 	}
+	// Lookups within a process share one connection, so they never contend
+	// for the write lock; other processes wait for it via busy_timeout.
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
 
 	if err := initSchema(db); err != nil {
 		_ = db.Close()
 		return nil, err
 	}
 
-	return &Cache{db: db}, nil
+	return &Cache{db: db, now: time.Now}, nil
 }
 
-func initSchema(db *sql.DB) error {
-	// Check if we need to migrate (old schema without created_at)
-	var count int
-	err := db.QueryRow(`
-		SELECT COUNT(*) FROM pragma_table_info('cache') WHERE name = 'created_at'
-	`).Scan(&count)
-	if err != nil && err != sql.ErrNoRows {
-		return err // Propagate unexpected errors
-	}
+// SetLimits bounds the cache size. Entries over the limits are evicted,
+// least recently used first, on the next Set.
+func (c *Cache) SetLimits(limits CacheLimits) {
+	c.limits = limits
+}
 
-	if err == nil && count == 0 {
-		// Old schema exists, drop it (it's just a cache)
-		if _, execErr := db.Exec("DROP TABLE IF EXISTS cache"); execErr != nil {
-			return execErr
-		}
+func defaultCachePath() (string, error) {
+	dir, err := defaultCacheDir()
+	if err != nil {
+		return "", err
 	}
-
-	_, err = db.Exec(`
-		CREATE TABLE IF NOT EXISTS cache (
-			key        TEXT PRIMARY KEY,
-			value      BLOB,
-			created_at INTEGER
-		)
-	`)
-	return err
+	return filepath.Join(dir, "cache.db"), nil
 }
 
-func defaultCachePath() (string, error) {
+// defaultCacheDir returns the directory holding the cache of every backend.
+func defaultCacheDir() (string, error) {
 	// Prefer XDG_CACHE_HOME, fall back to ~/.cache
 	cacheDir := os.Getenv("XDG_CACHE_HOME")
 	if cacheDir == "" {
@@ -87,35 +103,100 @@ func defaultCachePath() (string, error) {
 		}
 		cacheDir = filepath.Join(home, ".cache")
 	}
-	return filepath.Join(cacheDir, "sonaveeb", "cache.db"), nil
+	return filepath.Join(cacheDir, "sonaveeb"), nil
 }
 
-// Get retrieves a value from the cache. Returns nil if not found.
+// accessResolution is how old accessed_at must be before Get updates it.
+// LRU eviction only needs a rough order, and skipping the update keeps most
+// cache hits read-only, so they don't wait for another process's writes.
+const accessResolution = time.Hour
+
+// Get retrieves a value from the cache and marks it as recently used.
+// Returns nil if not found.
 func (c *Cache) Get(key string) (*CacheEntry, error) {
-	var value []byte
+	var stored []byte
+	var encoding string
 	var createdAt int64
+	var accessedAt sql.NullInt64
 	err := c.db.QueryRow(
-		"SELECT value, created_at FROM cache WHERE key = ?", key,
-	).Scan(&value, &createdAt)
+		"SELECT value, encoding, created_at, accessed_at FROM cache WHERE key = ?", key,
+	).Scan(&stored, &encoding, &createdAt, &accessedAt)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, err
 	}
-	return &CacheEntry{
-		Value:     value,
-		CreatedAt: time.Unix(createdAt, 0),
-	}, nil
+
+	value, err := decodeValue(stored, encoding)
+	if err != nil {
+		return nil, fmt.Errorf("cache entry %q: %w", key, err)
+	}
+
+	entry := &CacheEntry{
+		Value:      value,
+		CreatedAt:  time.Unix(createdAt, 0),
+		AccessedAt: time.Unix(accessedAt.Int64, 0),
+	}
+
+	// Marking the entry as used is best-effort: the value is already read,
+	// and failing to record the access only makes eviction less precise.
+	now := c.now()
+	if !accessedAt.Valid || now.Sub(entry.AccessedAt) >= accessResolution {
+		_, _ = c.db.Exec("UPDATE cache SET accessed_at = ? WHERE key = ?", now.Unix(), key)
+	}
+	return entry, nil
 }
 
-// Set stores a value in the cache.
+// Set stores a value in the cache, compressing it if worthwhile, and
+// evicts least-recently-used entries if the cache is over its limits.
 func (c *Cache) Set(key string, value []byte) error {
-	_, err := c.db.Exec(
-		"INSERT OR REPLACE INTO cache (key, value, created_at) VALUES (?, ?, ?)",
-		key, value, time.Now().Unix(),
+	stored, encoding, err := encodeValue(value)
+	if err != nil {
+		return err
+	}
+
+	now := c.now().Unix()
+	_, err = c.db.Exec(
+		`INSERT OR REPLACE INTO cache (key, value, encoding, raw_size, created_at, accessed_at)
+		 VALUES (?, ?, ?, ?, ?, ?)`,
+		key, stored, encoding, len(value), now, now,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	return c.evict()
+}
+
+// evict removes least-recently-used entries until the cache is within its
+// limits. Ties on access time are broken by key for determinism.
+func (c *Cache) evict() error {
+	if c.limits.MaxEntries > 0 {
+		_, err := c.db.Exec(`
+			DELETE FROM cache WHERE key IN (
+				SELECT key FROM cache ORDER BY accessed_at DESC, key LIMIT -1 OFFSET ?
+			)
+		`, c.limits.MaxEntries)
+		if err != nil {
+			return err
+		}
+	}
+
+	if c.limits.MaxBytes > 0 {
+		_, err := c.db.Exec(`
+			DELETE FROM cache WHERE key IN (
+				SELECT key FROM (
+					SELECT key, SUM(length(value)) OVER (ORDER BY accessed_at DESC, key) AS running
+					FROM cache
+				) WHERE running > ?
+			)
+		`, c.limits.MaxBytes)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // Delete removes a key from the cache.
@@ -130,6 +211,105 @@ func (c *Cache) Clear() error {
 	return err
 }
 
+// CachePrefixStats summarises the entries sharing a key prefix
+// such as "search:" or "details:".
+type CachePrefixStats struct {
+	Prefix      string
+	Entries     int
+	Bytes       int64 // Uncompressed size of the values
+	StoredBytes int64 // Size on disk after compression
+}
+
+// CompressionRatio returns how many times smaller the stored values are
+// than the originals; 1 means no savings.
+func (s CachePrefixStats) CompressionRatio() float64 {
+	if s.StoredBytes == 0 {
+		return 1
+	}
+	return float64(s.Bytes) / float64(s.StoredBytes)
+}
+
+// CacheKeyInfo describes a cached entry without its value.
+type CacheKeyInfo struct {
+	Key        string
+	Size       int64 // Uncompressed size of the value, as in CachePrefixStats.Bytes
+	CreatedAt  time.Time
+	AccessedAt time.Time
+}
+
+// Stats returns entry counts and total value sizes grouped by key prefix
+// (everything up to and including the first colon), ordered by prefix.
+func (c *Cache) Stats() ([]CachePrefixStats, error) {
+	rows, err := c.db.Query(`
+		SELECT substr(key, 1, instr(key, ':')) AS prefix, COUNT(*),
+			COALESCE(SUM(COALESCE(raw_size, length(value))), 0), COALESCE(SUM(length(value)), 0)
+		FROM cache GROUP BY prefix ORDER BY prefix
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var stats []CachePrefixStats
+	for rows.Next() {
+		var s CachePrefixStats
+		if err := rows.Scan(&s.Prefix, &s.Entries, &s.Bytes, &s.StoredBytes); err != nil {
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
+
+// List returns the keys matching a glob pattern (as in SQLite GLOB: *, ?
+// and [...]), ordered by key. An empty pattern lists every key.
+func (c *Cache) List(pattern string) ([]CacheKeyInfo, error) {
+	if pattern == "" {
+		pattern = "*"
+	}
+	rows, err := c.db.Query(
+		"SELECT key, COALESCE(raw_size, length(value)), created_at, accessed_at FROM cache WHERE key GLOB ? ORDER BY key", pattern,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var keys []CacheKeyInfo
+	for rows.Next() {
+		var info CacheKeyInfo
+		var createdAt int64
+		var accessedAt sql.NullInt64
+		if err := rows.Scan(&info.Key, &info.Size, &createdAt, &accessedAt); err != nil {
+			return nil, err
+		}
+		info.CreatedAt = time.Unix(createdAt, 0)
+		info.AccessedAt = time.Unix(accessedAt.Int64, 0)
+		keys = append(keys, info)
+	}
+	return keys, rows.Err()
+}
+
+// DeleteMatching removes all keys matching a glob pattern and returns how
+// many were removed.
+func (c *Cache) DeleteMatching(pattern string) (int64, error) {
+	res, err := c.db.Exec("DELETE FROM cache WHERE key GLOB ?", pattern)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// DeleteOlderThan removes entries created before t and returns how many
+// were removed.
+func (c *Cache) DeleteOlderThan(t time.Time) (int64, error) {
+	res, err := c.db.Exec("DELETE FROM cache WHERE created_at < ?", t.Unix())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
 // Close closes the cache database.
 func (c *Cache) Close() error {
 	return c.db.Close()
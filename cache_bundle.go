@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+)
+
+// Cache bundles are gzip-compressed NDJSON: a header line, one line per
+// entry, and a trailer line with the entry count and a SHA-256 checksum of
+// every line before it.
+const (
+	bundleFormat  = "sonaveeb-cache"
+	bundleVersion = 1
+)
+
+type bundleHeader struct {
+	Format    string `json:"format"`
+	Version   int    `json:"version"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+type bundleEntry struct {
+	Key       string `json:"key"`
+	Value     []byte `json:"value"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+type bundleTrailer struct {
+	Entries int    `json:"entries"`
+	SHA256  string `json:"sha256"`
+}
+
+// ImportResult counts what happened to each entry of an imported bundle.
+type ImportResult struct {
+	Added   int // Keys that were not cached before
+	Updated int // Keys replaced by a newer entry from the bundle
+	Skipped int // Keys where the local entry was as new or newer
+}
+
+// Export writes every cache entry to w as a bundle and returns the number
+// of entries written.
+func (c *Cache) Export(w io.Writer) (int, error) {
+	zw := gzip.NewWriter(w)
+	sum := sha256.New()
+	out := io.MultiWriter(zw, sum)
+	enc := json.NewEncoder(out)
+
+	if err := enc.Encode(bundleHeader{Format: bundleFormat, Version: bundleVersion, CreatedAt: c.now().Unix()}); err != nil {
+		return 0, err
+	}
+
+	rows, err := c.db.Query("SELECT key, value, encoding, created_at FROM cache ORDER BY key")
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	count := 0
+	for rows.Next() {
+		var e bundleEntry
+		var stored []byte
+		var encoding string
+		if err := rows.Scan(&e.Key, &stored, &encoding, &e.CreatedAt); err != nil {
+			return count, err
+		}
+		if e.Value, err = decodeValue(stored, encoding); err != nil {
+			return count, fmt.Errorf("cache entry %q: %w", e.Key, err)
+		}
+		if err := enc.Encode(e); err != nil {
+			return count, err
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return count, err
+	}
+
+	// The trailer goes to the compressed stream only; it is not part of the checksum
+	trailer := bundleTrailer{Entries: count, SHA256: hex.EncodeToString(sum.Sum(nil))}
+	if err := json.NewEncoder(zw).Encode(trailer); err != nil {
+		return count, err
+	}
+	return count, zw.Close()
+}
+
+// Import merges a bundle into the cache. When a key exists on both sides,
+// the entry with the newer created_at wins. The bundle is verified against
+// its checksum before any change is committed.
+func (c *Cache) Import(r io.Reader) (ImportResult, error) {
+	var result ImportResult
+
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return result, fmt.Errorf("not a cache bundle: %w", err)
+	}
+	defer func() { _ = zr.Close() }()
+
+	lines := bufio.NewReader(zr)
+	sum := sha256.New()
+
+	var header bundleHeader
+	if err := readBundleLine(lines, sum, &header); err != nil {
+		return result, fmt.Errorf("reading bundle header: %w", err)
+	}
+	if header.Format != bundleFormat {
+		return result, errors.New("not a cache bundle")
+	}
+	if header.Version != bundleVersion {
+		return result, fmt.Errorf("unsupported cache bundle version %d", header.Version)
+	}
+
+	tx, err := c.db.Begin()
+	if err != nil {
+		return result, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	now := c.now().Unix()
+	count := 0
+	for {
+		line, err := lines.ReadBytes('\n')
+		if err != nil {
+			return result, fmt.Errorf("bundle is truncated: %w", err)
+		}
+
+		// The trailer is the only line with a checksum
+		var trailer bundleTrailer
+		if json.Unmarshal(line, &trailer) == nil && trailer.SHA256 != "" {
+			if trailer.Entries != count {
+				return result, fmt.Errorf("bundle has %d entries, trailer says %d", count, trailer.Entries)
+			}
+			if got := hex.EncodeToString(sum.Sum(nil)); got != trailer.SHA256 {
+				return result, errors.New("bundle checksum mismatch")
+			}
+			break
+		}
+
+		sum.Write(line)
+		var e bundleEntry
+		if err := json.Unmarshal(line, &e); err != nil || e.Key == "" {
+			return result, fmt.Errorf("invalid bundle entry on line %d", count+2)
+		}
+		count++
+
+		outcome, err := importEntry(tx, e, now)
+		if err != nil {
+			return result, err
+		}
+		switch outcome {
+		case importAdded:
+			result.Added++
+		case importUpdated:
+			result.Updated++
+		default:
+			result.Skipped++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return result, err
+	}
+	return result, c.evict()
+}
+
+func readBundleLine(r *bufio.Reader, sum hash.Hash, v any) error {
+	line, err := r.ReadBytes('\n')
+	if err != nil {
+		return err
+	}
+	sum.Write(line)
+	return json.Unmarshal(line, v)
+}
+
+type importOutcome int
+
+const (
+	importSkipped importOutcome = iota
+	importAdded
+	importUpdated
+)
+
+// importEntry writes e unless the cache already holds an entry for the
+// same key that is at least as new.
+func importEntry(tx *sql.Tx, e bundleEntry, now int64) (importOutcome, error) {
+	var existing int64
+	err := tx.QueryRow("SELECT created_at FROM cache WHERE key = ?", e.Key).Scan(&existing)
+	outcome := importUpdated
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		outcome = importAdded
+	case err != nil:
+		return importSkipped, err
+	case existing >= e.CreatedAt:
+		return importSkipped, nil
+	}
+
+	stored, encoding, err := encodeValue(e.Value)
+	if err != nil {
+		return importSkipped, err
+	}
+	_, err = tx.Exec(
+		`INSERT OR REPLACE INTO cache (key, value, encoding, raw_size, created_at, accessed_at)
+		 VALUES (?, ?, ?, ?, ?, ?)`,
+		e.Key, stored, encoding, len(e.Value), e.CreatedAt, now,
+	)
+	return outcome, err
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func exportBundle(t *testing.T, c *Cache) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if _, err := c.Export(&buf); err != nil {
+		t.Fatalf("Export() error: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// rewriteBundle decompresses a bundle, applies edit, and recompresses it.
+func rewriteBundle(t *testing.T, bundle []byte, edit func(string) string) []byte {
+	t.Helper()
+	zr, err := gzip.NewReader(bytes.NewReader(bundle))
+	if err != nil {
+		t.Fatalf("gzip error: %v", err)
+	}
+	plain, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("read error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, _ = zw.Write([]byte(edit(string(plain))))
+	_ = zw.Close()
+	return buf.Bytes()
+}
+
+func TestCacheBundle_RoundTrip(t *testing.T) {
+	src := openTestCache(t)
+	large := []byte(strings.Repeat(`{"value":"tegema","morphCode":"Sup"},`, 50))
+	entries := map[string][]byte{
+		"search:puu": []byte(`{"words":[]}`),
+		"details:1":  []byte(`{"wordClass":"noun"}`),
+		"paradigm:1": large,
+	}
+	for k, v := range entries {
+		if err := src.Set(k, v); err != nil {
+			t.Fatalf("Set(%q) error: %v", k, err)
+		}
+	}
+	setCreatedAt(t, src, "details:1", time.Unix(1700000000, 0))
+
+	bundle := exportBundle(t, src)
+
+	dst := openTestCache(t)
+	result, err := dst.Import(bytes.NewReader(bundle))
+	if err != nil {
+		t.Fatalf("Import() error: %v", err)
+	}
+	if result.Added != 3 || result.Updated != 0 || result.Skipped != 0 {
+		t.Errorf("unexpected import result: %+v", result)
+	}
+
+	for k, want := range entries {
+		entry, err := dst.Get(k)
+		if err != nil || entry == nil {
+			t.Fatalf("Get(%q) = %+v, %v", k, entry, err)
+		}
+		if !bytes.Equal(entry.Value, want) {
+			t.Errorf("Get(%q) returned different data", k)
+		}
+	}
+
+	entry, _ := dst.Get("details:1")
+	if entry.CreatedAt.Unix() != 1700000000 {
+		t.Errorf("expected created_at preserved, got %v", entry.CreatedAt)
+	}
+}
+
+func TestCacheBundle_NewestWins(t *testing.T) {
+	src := openTestCache(t)
+	dst := openTestCache(t)
+
+	older := time.Unix(1700000000, 0)
+	newer := time.Unix(1700100000, 0)
+
+	set := func(c *Cache, key, value string, createdAt time.Time) {
+		t.Helper()
+		if err := c.Set(key, []byte(value)); err != nil {
+			t.Fatalf("Set(%q) error: %v", key, err)
+		}
+		setCreatedAt(t, c, key, createdAt)
+	}
+
+	set(src, "search:a", "bundle a", newer) // bundle newer: replaces local
+	set(dst, "search:a", "local a", older)
+	set(src, "search:b", "bundle b", older) // local newer: kept
+	set(dst, "search:b", "local b", newer)
+	set(src, "search:c", "bundle c", older) // same age: local kept
+	set(dst, "search:c", "local c", older)
+	set(dst, "search:d", "local d", older) // only local: untouched
+
+	result, err := dst.Import(bytes.NewReader(exportBundle(t, src)))
+	if err != nil {
+		t.Fatalf("Import() error: %v", err)
+	}
+	if result.Added != 0 || result.Updated != 1 || result.Skipped != 2 {
+		t.Errorf("unexpected import result: %+v", result)
+	}
+
+	want := map[string]string{
+		"search:a": "bundle a",
+		"search:b": "local b",
+		"search:c": "local c",
+		"search:d": "local d",
+	}
+	for k, v := range want {
+		entry, err := dst.Get(k)
+		if err != nil || entry == nil {
+			t.Fatalf("Get(%q) = %+v, %v", k, entry, err)
+		}
+		if string(entry.Value) != v {
+			t.Errorf("Get(%q) = %q, want %q", k, entry.Value, v)
+		}
+	}
+}
+
+func TestCacheBundle_ImportEvictsOverLimits(t *testing.T) {
+	src := openTestCache(t)
+	for _, k := range []string{"search:a", "search:b", "search:c"} {
+		if err := src.Set(k, []byte(`{"words":[]}`)); err != nil {
+			t.Fatalf("Set(%q) error: %v", k, err)
+		}
+	}
+
+	dst := openTestCache(t)
+	dst.SetLimits(CacheLimits{MaxEntries: 2})
+	if _, err := dst.Import(bytes.NewReader(exportBundle(t, src))); err != nil {
+		t.Fatalf("Import() error: %v", err)
+	}
+	keys, err := dst.List("")
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if len(keys) != 2 {
+		t.Errorf("expected import to evict down to 2 entries, got %d", len(keys))
+	}
+}
+
+func TestCacheBundle_RejectsDamagedBundles(t *testing.T) {
+	src := openTestCache(t)
+	for _, k := range []string{"search:puu", "search:maja"} {
+		if err := src.Set(k, []byte(`{"words":[]}`)); err != nil {
+			t.Fatalf("Set() error: %v", err)
+		}
+	}
+	bundle := exportBundle(t, src)
+
+	tests := []struct {
+		name   string
+		bundle []byte
+	}{
+		{"not gzip", []byte("hello")},
+		{"tampered entry", rewriteBundle(t, bundle, func(s string) string {
+			return strings.Replace(s, `"search:puu"`, `"search:puy"`, 1)
+		})},
+		{"truncated", rewriteBundle(t, bundle, func(s string) string {
+			lines := strings.SplitAfter(s, "\n")
+			return strings.Join(lines[:2], "")
+		})},
+		{"wrong format", rewriteBundle(t, bundle, func(s string) string {
+			return strings.Replace(s, bundleFormat, "something-else", 1)
+		})},
+		{"future version", rewriteBundle(t, bundle, func(s string) string {
+			return strings.Replace(s, `"version":1`, `"version":99`, 1)
+		})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := openTestCache(t)
+			if _, err := dst.Import(bytes.NewReader(tt.bundle)); err == nil {
+				t.Fatal("expected import error")
+			}
+			keys, err := dst.List("")
+			if err != nil {
+				t.Fatalf("List() error: %v", err)
+			}
+			if len(keys) != 0 {
+				t.Errorf("expected nothing imported from a damaged bundle, got %+v", keys)
+			}
+		})
+	}
+}
+
+func TestRunCacheCommand_ExportImport(t *testing.T) {
+	src := openTestCache(t)
+	if err := src.Set("search:puu", []byte(`{"words":[]}`)); err != nil {
+		t.Fatalf("Set() error: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "cache.bundle")
+	var out bytes.Buffer
+	if err := runCacheCommand(src, []string{"export", path}, &out); err != nil {
+		t.Fatalf("cache export: %v", err)
+	}
+	if out.String() != "Exported 1 entry\n" {
+		t.Errorf("unexpected export output: %q", out.String())
+	}
+
+	dst := openTestCache(t)
+	out.Reset()
+	if err := runCacheCommand(dst, []string{"import", path}, &out); err != nil {
+		t.Fatalf("cache import: %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "Imported 1 new, 0 updated, 0 skipped") {
+		t.Errorf("unexpected import output: %q", out.String())
+	}
+	if entry, _ := dst.Get("search:puu"); entry == nil {
+		t.Error("expected search:puu after import")
+	}
+}
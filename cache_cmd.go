@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+// errCacheUsage is returned for malformed "cache" subcommand invocations.
+var errCacheUsage = errors.New("usage error")
+
+const cacheUsage = `Usage: sonaveeb-cli cache <command> [args]
+
+Commands:
+  stats              Entry counts, sizes and compression per key prefix
+  list [pattern]     List keys with size, age and last use (glob, e.g. 'search:*')
+  inspect <key>      Print a cached entry's JSON
+  delete <pattern>... Delete keys matching the given keys or globs
+  prune <age>        Delete entries older than age (e.g. 720h)
+  clear              Delete every entry
+  export <file>      Write all entries to a portable bundle (- for stdout)
+  import <file>      Merge a bundle into the cache; newer entries win (- for stdin)
+`
+
+// runCacheCommand executes a "cache" subcommand against c, writing results to w.
+func runCacheCommand(c *Cache, args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: missing cache command", errCacheUsage)
+	}
+
+	cmd, args := args[0], args[1:]
+	switch cmd {
+	case "stats":
+		return cacheStats(c, w)
+	case "list":
+		if len(args) > 1 {
+			return fmt.Errorf("%w: list takes at most one pattern", errCacheUsage)
+		}
+		pattern := ""
+		if len(args) == 1 {
+			pattern = args[0]
+		}
+		return cacheList(c, pattern, w, time.Now())
+	case "inspect":
+		if len(args) != 1 {
+			return fmt.Errorf("%w: inspect takes exactly one key", errCacheUsage)
+		}
+		return cacheInspect(c, args[0], w)
+	case "delete":
+		if len(args) == 0 {
+			return fmt.Errorf("%w: delete needs at least one key or pattern", errCacheUsage)
+		}
+		return cacheDelete(c, args, w)
+	case "prune":
+		if len(args) != 1 {
+			return fmt.Errorf("%w: prune takes exactly one age", errCacheUsage)
+		}
+		age, err := time.ParseDuration(args[0])
+		if err != nil || age <= 0 {
+			return fmt.Errorf("%w: invalid age %q (use e.g. 720h)", errCacheUsage, args[0])
+		}
+		return cachePrune(c, age, w, time.Now())
+	case "export":
+		if len(args) != 1 {
+			return fmt.Errorf("%w: export takes exactly one file", errCacheUsage)
+		}
+		return cacheExport(c, args[0], w)
+	case "import":
+		if len(args) != 1 {
+			return fmt.Errorf("%w: import takes exactly one file", errCacheUsage)
+		}
+		return cacheImport(c, args[0], w)
+	case "clear":
+		if err := c.Clear(); err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintln(w, "Cache cleared")
+		return nil
+	}
+	return fmt.Errorf("%w: unknown cache command %q", errCacheUsage, cmd)
+}
+
+func cacheStats(c *Cache, w io.Writer) error {
+	stats, err := c.Stats()
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "PREFIX\tENTRIES\tSIZE\tSTORED\tRATIO")
+	total := CachePrefixStats{Prefix: "total"}
+	for _, s := range stats {
+		if s.Prefix == "" {
+			s.Prefix = "(other)"
+		}
+		writeStatsRow(tw, s)
+		total.Entries += s.Entries
+		total.Bytes += s.Bytes
+		total.StoredBytes += s.StoredBytes
+	}
+	writeStatsRow(tw, total)
+	return tw.Flush()
+}
+
+func writeStatsRow(w io.Writer, s CachePrefixStats) {
+	_, _ = fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%.1fx\n", s.Prefix, s.Entries,
+		formatBytes(s.Bytes), formatBytes(s.StoredBytes), s.CompressionRatio())
+}
+
+func cacheList(c *Cache, pattern string, w io.Writer, now time.Time) error {
+	keys, err := c.List(pattern)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "KEY\tSIZE\tAGE\tLAST USED")
+	for _, k := range keys {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s ago\n", k.Key, formatBytes(k.Size),
+			formatAge(now.Sub(k.CreatedAt)), formatAge(now.Sub(k.AccessedAt)))
+	}
+	return tw.Flush()
+}
+
+func cacheInspect(c *Cache, key string, w io.Writer) error {
+	entry, err := c.Get(key)
+	if err != nil {
+		return err
+	}
+	if entry == nil {
+		return fmt.Errorf("not in cache: %s", key)
+	}
+
+	var pretty bytes.Buffer
+	if err := json.Indent(&pretty, entry.Value, "", "  "); err != nil {
+		// Not JSON; dump it as-is
+		_, err = w.Write(entry.Value)
+		return err
+	}
+	pretty.WriteByte('\n')
+	_, err = w.Write(pretty.Bytes())
+	return err
+}
+
+func cacheDelete(c *Cache, patterns []string, w io.Writer) error {
+	var total int64
+	for _, p := range patterns {
+		n, err := c.DeleteMatching(p)
+		if err != nil {
+			return err
+		}
+		total += n
+	}
+	_, _ = fmt.Fprintf(w, "Deleted %d %s\n", total, plural(total, "entry", "entries"))
+	return nil
+}
+
+func cachePrune(c *Cache, age time.Duration, w io.Writer, now time.Time) error {
+	n, err := c.DeleteOlderThan(now.Add(-age))
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(w, "Pruned %d %s older than %s\n", n, plural(n, "entry", "entries"), formatAge(age))
+	return nil
+}
+
+func cacheExport(c *Cache, path string, w io.Writer) error {
+	out := w
+	var f *os.File
+	if path != "-" {
+		var err error
+		if f, err = os.Create(path); err != nil {
+			return err
+		}
+		out = f
+	}
+
+	n, err := c.Export(out)
+	if f != nil {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	// Keep stdout clean when the bundle itself is written there
+	msg := w
+	if path == "-" {
+		msg = os.Stderr
+	}
+	_, _ = fmt.Fprintf(msg, "Exported %d %s\n", n, plural(int64(n), "entry", "entries"))
+	return nil
+}
+
+func cacheImport(c *Cache, path string, w io.Writer) error {
+	var in io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+		in = f
+	}
+
+	result, err := c.Import(in)
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(w, "Imported %d new, %d updated, %d skipped (local copy as new or newer)\n",
+		result.Added, result.Updated, result.Skipped)
+	return nil
+}
+
+func plural(n int64, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
+
+// formatAge renders a duration in its largest whole unit, e.g. "3d" or "5h".
+func formatAge(d time.Duration) string {
+	switch {
+	case d < 0:
+		return "0s"
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d >= time.Minute:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	}
+	return fmt.Sprintf("%ds", d/time.Second)
+}
+
+// formatBytes renders a size using binary units, e.g. "1.5 KiB".
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunCacheCommand(t *testing.T) {
+	cache := openTestCache(t)
+	for k, v := range map[string]string{
+		"search:puu": `{"words":[{"wordId":1}]}`,
+		"details:1":  `{"wordClass":"noun"}`,
+		"paradigm:1": `[]`,
+	} {
+		if err := cache.Set(k, []byte(v)); err != nil {
+			t.Fatalf("failed to set %q: %v", k, err)
+		}
+	}
+
+	run := func(args ...string) string {
+		t.Helper()
+		var out bytes.Buffer
+		if err := runCacheCommand(cache, args, &out); err != nil {
+			t.Fatalf("cache %s: %v", strings.Join(args, " "), err)
+		}
+		return out.String()
+	}
+
+	t.Run("stats", func(t *testing.T) {
+		out := run("stats")
+		for _, want := range []string{"PREFIX", "search:", "details:", "paradigm:", "total"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("stats output missing %q:\n%s", want, out)
+			}
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		out := run("list", "search:*")
+		if !strings.Contains(out, "search:puu") || strings.Contains(out, "details:1") {
+			t.Errorf("unexpected list output:\n%s", out)
+		}
+	})
+
+	t.Run("inspect pretty-prints JSON", func(t *testing.T) {
+		out := run("inspect", "details:1")
+		if out != "{\n  \"wordClass\": \"noun\"\n}\n" {
+			t.Errorf("unexpected inspect output: %q", out)
+		}
+	})
+
+	t.Run("inspect missing key", func(t *testing.T) {
+		err := runCacheCommand(cache, []string{"inspect", "details:999"}, &bytes.Buffer{})
+		if err == nil || !strings.Contains(err.Error(), "not in cache") {
+			t.Errorf("expected not in cache error, got %v", err)
+		}
+	})
+
+	t.Run("prune", func(t *testing.T) {
+		setCreatedAt(t, cache, "paradigm:1", time.Now().Add(-10*24*time.Hour))
+		out := run("prune", "168h")
+		if out != "Pruned 1 entry older than 7d\n" {
+			t.Errorf("unexpected prune output: %q", out)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		out := run("delete", "search:*", "details:1")
+		if out != "Deleted 2 entries\n" {
+			t.Errorf("unexpected delete output: %q", out)
+		}
+	})
+
+	t.Run("usage errors", func(t *testing.T) {
+		for _, args := range [][]string{
+			{},
+			{"bogus"},
+			{"inspect"},
+			{"prune", "soon"},
+			{"delete"},
+		} {
+			err := runCacheCommand(cache, args, &bytes.Buffer{})
+			if !errors.Is(err, errCacheUsage) {
+				t.Errorf("cache %v: expected usage error, got %v", args, err)
+			}
+		}
+	})
+}
+
+func TestFormatAge(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{30 * time.Second, "30s"},
+		{5 * time.Minute, "5m"},
+		{3 * time.Hour, "3h"},
+		{50 * time.Hour, "2d"},
+		{-time.Second, "0s"},
+	}
+	for _, tt := range tests {
+		if got := formatAge(tt.d); got != tt.want {
+			t.Errorf("formatAge(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1536, "1.5 KiB"},
+		{5 * 1024 * 1024, "5.0 MiB"},
+	}
+	for _, tt := range tests {
+		if got := formatBytes(tt.n); got != tt.want {
+			t.Errorf("formatBytes(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
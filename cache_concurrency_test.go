@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+const stressWrites = 50
+
+// writeStressKeys sets and reads back stressWrites keys under prefix.
+func writeStressKeys(c *Cache, prefix string) error {
+	for i := 0; i < stressWrites; i++ {
+		key := fmt.Sprintf("%s:%d", prefix, i)
+		if err := c.Set(key, []byte(key)); err != nil {
+			return fmt.Errorf("Set(%q): %w", key, err)
+		}
+		entry, err := c.Get(key)
+		if err != nil {
+			return fmt.Errorf("Get(%q): %w", key, err)
+		}
+		if entry == nil || string(entry.Value) != key {
+			return fmt.Errorf("Get(%q) = %v right after Set", key, entry)
+		}
+	}
+	return nil
+}
+
+// TestCacheHelperProcess is not a real test: it is run in a child process
+// by TestCache_ConcurrentProcesses to write to a shared cache.
+func TestCacheHelperProcess(t *testing.T) {
+	path := os.Getenv("SONAVEEB_STRESS_CACHE")
+	if path == "" {
+		t.Skip("helper process")
+	}
+	c, err := OpenCacheAt(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "OpenCacheAt: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = c.Close() }()
+	if err := writeStressKeys(c, os.Getenv("SONAVEEB_STRESS_PREFIX")); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func assertStressKeys(t *testing.T, c *Cache, prefixes []string) {
+	t.Helper()
+	for _, prefix := range prefixes {
+		keys, err := c.List(prefix + ":*")
+		if err != nil {
+			t.Fatalf("List() error: %v", err)
+		}
+		if len(keys) != stressWrites {
+			t.Errorf("%s: %d keys, want %d", prefix, len(keys), stressWrites)
+		}
+	}
+}
+
+func TestCache_ConcurrentGoroutines(t *testing.T) {
+	c := openTestCache(t)
+
+	var prefixes []string
+	errs := make(chan error, 8)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		prefix := "g" + strconv.Itoa(g)
+		prefixes = append(prefixes, prefix)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- writeStressKeys(c, prefix)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	assertStressKeys(t, c, prefixes)
+}
+
+func TestCache_ConcurrentProcesses(t *testing.T) {
+	if testing.Short() {
+		t.Skip("spawns processes")
+	}
+	// Every process, including this one, races to create the database
+	path := filepath.Join(t.TempDir(), "shared.db")
+
+	var prefixes []string
+	var cmds []*exec.Cmd
+	for p := 0; p < 4; p++ {
+		prefix := "p" + strconv.Itoa(p)
+		prefixes = append(prefixes, prefix)
+		cmd := exec.Command(os.Args[0], "-test.run=^TestCacheHelperProcess$")
+		cmd.Env = append(os.Environ(), "SONAVEEB_STRESS_CACHE="+path, "SONAVEEB_STRESS_PREFIX="+prefix)
+		cmd.Stderr = os.Stderr
+		if err := cmd.Start(); err != nil {
+			t.Fatalf("starting helper: %v", err)
+		}
+		cmds = append(cmds, cmd)
+	}
+
+	c, err := OpenCacheAt(path)
+	if err != nil {
+		t.Fatalf("OpenCacheAt() error: %v", err)
+	}
+	defer func() { _ = c.Close() }()
+
+	prefixes = append(prefixes, "parent")
+	if err := writeStressKeys(c, "parent"); err != nil {
+		t.Error(err)
+	}
+
+	for _, cmd := range cmds {
+		if err := cmd.Wait(); err != nil {
+			t.Errorf("helper process failed: %v", err)
+		}
+	}
+	assertStressKeys(t, c, prefixes)
+}
+
+func TestOpenCacheAt_UsesWAL(t *testing.T) {
+	c := openTestCache(t)
+
+	var mode string
+	if err := c.db.QueryRow("PRAGMA journal_mode").Scan(&mode); err != nil {
+		t.Fatalf("PRAGMA journal_mode: %v", err)
+	}
+	if mode != "wal" {
+		t.Errorf("journal_mode = %q, want wal", mode)
+	}
+}
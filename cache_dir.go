@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DirCache is a CacheStore keeping one plain file per entry in a
+// directory, for environments where SQLite databases are unwelcome.
+// A file's modification time is its creation time; access times are not
+// tracked, so AccessedAt always equals CreatedAt.
+type DirCache struct {
+	dir string
+	now func() time.Time
+}
+
+// OpenDirCache opens or creates a directory cache at dir.
+func OpenDirCache(dir string) (*DirCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &DirCache{dir: dir, now: time.Now}, nil
+}
+
+// cacheFileName escapes a key into a file name. Everything except lower
+// case letters, digits, '-' and '_' is percent-encoded, so keys stay
+// distinct on case-insensitive file systems and never contain separators.
+func cacheFileName(key string) string {
+	var sb strings.Builder
+	for i := 0; i < len(key); i++ {
+		b := key[i]
+		if b >= 'a' && b <= 'z' || b >= '0' && b <= '9' || b == '-' || b == '_' {
+			sb.WriteByte(b)
+		} else {
+			fmt.Fprintf(&sb, "%%%02X", b)
+		}
+	}
+	return sb.String()
+}
+
+func (c *DirCache) path(key string) string {
+	return filepath.Join(c.dir, cacheFileName(key))
+}
+
+// Get retrieves a value from the cache. Returns nil if not found.
+func (c *DirCache) Get(key string) (*CacheEntry, error) {
+	path := c.path(key)
+	value, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	return &CacheEntry{Value: value, CreatedAt: info.ModTime(), AccessedAt: info.ModTime()}, nil
+}
+
+// Set stores a value in the cache. The file is written under a temporary
+// name and renamed, so concurrent readers never see a partial value.
+func (c *DirCache) Set(key string, value []byte) error {
+	tmp, err := os.CreateTemp(c.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(value); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	now := c.now()
+	if err := os.Chtimes(tmp.Name(), now, now); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path(key))
+}
+
+// Delete removes a key from the cache.
+func (c *DirCache) Delete(key string) error {
+	err := os.Remove(c.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Clear removes all entries from the cache.
+func (c *DirCache) Clear() error {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		if err := os.Remove(filepath.Join(c.dir, e.Name())); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close releases the cache. Files are always written through, so there
+// is nothing to flush.
+func (c *DirCache) Close() error {
+	return nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+)
+
+// Value encodings recorded in the cache's encoding column.
+const (
+	encodingRaw  = ""
+	encodingGzip = "gzip"
+)
+
+// compressThreshold is the smallest value worth compressing. Search results
+// and short word details are rarely this large; paradigms often are.
+const compressThreshold = 512
+
+// encodeValue compresses value if it is large enough and compression
+// actually saves space, returning the bytes to store and their encoding.
+func encodeValue(value []byte) ([]byte, string, error) {
+	if len(value) < compressThreshold {
+		return value, encodingRaw, nil
+	}
+
+	var buf bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := zw.Write(value); err != nil {
+		return nil, "", err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, "", err
+	}
+
+	if buf.Len() >= len(value) {
+		return value, encodingRaw, nil
+	}
+	return buf.Bytes(), encodingGzip, nil
+}
+
+// decodeValue reverses encodeValue.
+func decodeValue(stored []byte, encoding string) ([]byte, error) {
+	switch encoding {
+	case encodingRaw:
+		return stored, nil
+	case encodingGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(stored))
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = zr.Close() }()
+		return io.ReadAll(zr)
+	}
+	return nil, fmt.Errorf("unknown cache value encoding %q", encoding)
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// migration upgrades the cache schema from version-1 to version.
+// The current version is stored in SQLite's PRAGMA user_version.
+type migration struct {
+	version     int
+	description string
+	statements  []string
+}
+
+// migrations lists every schema change in order. Never edit or reorder an
+// existing entry; append a new one instead, and add a fixture for the
+// previous version under testdata/.
+var migrations = []migration{
+	{
+		version:     1,
+		description: "create cache table",
+		statements: []string{
+			`CREATE TABLE IF NOT EXISTS cache (key TEXT PRIMARY KEY, value BLOB)`,
+		},
+	},
+	{
+		version:     2,
+		description: "record creation time",
+		statements: []string{
+			// The age of existing entries is unknown, so treat them as
+			// ancient: still usable offline, but refreshed when online
+			`ALTER TABLE cache ADD COLUMN created_at INTEGER NOT NULL DEFAULT 0`,
+		},
+	},
+	{
+		version:     3,
+		description: "track last access for LRU eviction",
+		statements: []string{
+			`ALTER TABLE cache ADD COLUMN accessed_at INTEGER`,
+			`UPDATE cache SET accessed_at = created_at`,
+			`CREATE INDEX IF NOT EXISTS cache_accessed_at ON cache (accessed_at)`,
+		},
+	},
+	{
+		version:     4,
+		description: "support compressed values",
+		statements: []string{
+			// Existing rows are uncompressed, so their raw size is their length
+			`ALTER TABLE cache ADD COLUMN encoding TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE cache ADD COLUMN raw_size INTEGER`,
+			`UPDATE cache SET raw_size = length(value)`,
+		},
+	},
+}
+
+// schemaVersion is the version this build of the cache expects.
+var schemaVersion = migrations[len(migrations)-1].version
+
+func initSchema(db *sql.DB) error {
+	version, stored, err := currentSchemaVersion(db)
+	if err != nil {
+		return err
+	}
+	if version > schemaVersion {
+		return fmt.Errorf("cache schema version %d is newer than supported version %d", version, schemaVersion)
+	}
+
+	for _, m := range migrations {
+		if m.version <= version {
+			continue
+		}
+		if err := applyMigration(db, m); err != nil {
+			return fmt.Errorf("cache migration %d (%s): %w", m.version, m.description, err)
+		}
+		stored = m.version
+	}
+
+	// Stamp caches from before versioning that were already up to date
+	if stored != schemaVersion {
+		_, err = db.Exec(fmt.Sprintf("PRAGMA user_version = %d", schemaVersion))
+	}
+	return err
+}
+
+// currentSchemaVersion returns the schema version of db and the value
+// stored in PRAGMA user_version. Caches created before versioning store 0,
+// so their version is inferred from the columns.
+func currentSchemaVersion(db *sql.DB) (version, stored int, err error) {
+	if err := db.QueryRow("PRAGMA user_version").Scan(&stored); err != nil {
+		return 0, 0, err
+	}
+	if stored > 0 {
+		return stored, stored, nil
+	}
+
+	columns := make(map[string]bool)
+	rows, err := db.Query("SELECT name FROM pragma_table_info('cache')")
+	if err != nil {
+		return 0, 0, err
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return 0, 0, err
+		}
+		columns[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return 0, 0, err
+	}
+
+	switch {
+	case columns["accessed_at"]:
+		return 3, 0, nil
+	case columns["created_at"]:
+		return 2, 0, nil
+	case columns["key"]:
+		return 1, 0, nil
+	}
+	return 0, 0, nil
+}
+
+// applyMigration runs a migration and records its version atomically.
+// It is skipped if another process applied it since the version was read.
+func applyMigration(db *sql.DB, m migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var stored int
+	if err := tx.QueryRow("PRAGMA user_version").Scan(&stored); err != nil {
+		return err
+	}
+	if stored >= m.version {
+		return nil
+	}
+
+	for _, stmt := range m.statements {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	// PRAGMA does not accept bound parameters
+	if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", m.version)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// openFixture builds a database from testdata/<name>.sql and returns its path.
+func openFixture(t *testing.T, name string) string {
+	t.Helper()
+	script, err := os.ReadFile(filepath.Join("testdata", name+".sql"))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), name+".db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	if _, err := db.Exec(string(script)); err != nil {
+		t.Fatalf("failed to load fixture %s: %v", name, err)
+	}
+	return path
+}
+
+func userVersion(t *testing.T, c *Cache) int {
+	t.Helper()
+	var version int
+	if err := c.db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		t.Fatalf("failed to read user_version: %v", err)
+	}
+	return version
+}
+
+func TestMigrations_AreOrdered(t *testing.T) {
+	for i, m := range migrations {
+		if m.version != i+1 {
+			t.Errorf("migration %d has version %d, want %d", i, m.version, i+1)
+		}
+		if m.description == "" || len(m.statements) == 0 {
+			t.Errorf("migration %d is incomplete", m.version)
+		}
+	}
+}
+
+func TestMigrations_FromHistoricVersions(t *testing.T) {
+	tests := []struct {
+		fixture        string
+		wantCreatedAt  map[string]int64
+		wantAccessedAt map[string]int64
+	}{
+		{
+			fixture:        "cache_v1",
+			wantCreatedAt:  map[string]int64{"search:puu": 0, "details:1": 0},
+			wantAccessedAt: map[string]int64{"search:puu": 0, "details:1": 0},
+		},
+		{
+			fixture:        "cache_v2",
+			wantCreatedAt:  map[string]int64{"search:puu": 1700000000, "details:1": 1700000100},
+			wantAccessedAt: map[string]int64{"search:puu": 1700000000, "details:1": 1700000100},
+		},
+		{
+			fixture:        "cache_v3",
+			wantCreatedAt:  map[string]int64{"search:puu": 1700000000, "details:1": 1700000100},
+			wantAccessedAt: map[string]int64{"search:puu": 1700000500, "details:1": 1700000100},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			cache, err := OpenCacheAt(openFixture(t, tt.fixture))
+			if err != nil {
+				t.Fatalf("failed to open %s: %v", tt.fixture, err)
+			}
+			defer func() { _ = cache.Close() }()
+
+			if v := userVersion(t, cache); v != schemaVersion {
+				t.Errorf("user_version = %d, want %d", v, schemaVersion)
+			}
+
+			keys, err := cache.List("")
+			if err != nil {
+				t.Fatalf("List() error: %v", err)
+			}
+			if len(keys) != len(tt.wantCreatedAt) {
+				t.Fatalf("expected %d entries preserved, got %+v", len(tt.wantCreatedAt), keys)
+			}
+			for _, k := range keys {
+				if got := k.CreatedAt.Unix(); got != tt.wantCreatedAt[k.Key] {
+					t.Errorf("%s created_at = %d, want %d", k.Key, got, tt.wantCreatedAt[k.Key])
+				}
+				if got := k.AccessedAt.Unix(); got != tt.wantAccessedAt[k.Key] {
+					t.Errorf("%s accessed_at = %d, want %d", k.Key, got, tt.wantAccessedAt[k.Key])
+				}
+			}
+
+			entry, err := cache.Get("details:1")
+			if err != nil || entry == nil || string(entry.Value) != `{"wordClass":"noun"}` {
+				t.Errorf("expected details:1 readable after migration, got %+v, %v", entry, err)
+			}
+			if err := cache.Set("paradigm:1", []byte(`[]`)); err != nil {
+				t.Errorf("Set() after migration: %v", err)
+			}
+		})
+	}
+}
+
+func TestMigrations_FreshDatabase(t *testing.T) {
+	cache := openTestCache(t)
+	if v := userVersion(t, cache); v != schemaVersion {
+		t.Errorf("user_version = %d, want %d", v, schemaVersion)
+	}
+}
+
+func TestMigrations_ReopenIsNoOp(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	for i := 0; i < 2; i++ {
+		cache, err := OpenCacheAt(path)
+		if err != nil {
+			t.Fatalf("open %d: %v", i, err)
+		}
+		if err := cache.Set(fmt.Sprintf("k%d", i), []byte("v")); err != nil {
+			t.Fatalf("Set() error: %v", err)
+		}
+		_ = cache.Close()
+	}
+
+	cache, err := OpenCacheAt(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+	keys, _ := cache.List("")
+	if len(keys) != 2 {
+		t.Errorf("expected entries to survive reopening, got %+v", keys)
+	}
+}
+
+func TestMigrations_RejectsNewerVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "future.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	if _, err := db.Exec(fmt.Sprintf("PRAGMA user_version = %d", schemaVersion+1)); err != nil {
+		t.Fatalf("failed to set user_version: %v", err)
+	}
+	_ = db.Close()
+
+	if _, err := OpenCacheAt(path); err == nil {
+		t.Error("expected error opening a cache from a newer version")
+	}
+}
+
+func TestMigrations_PreserveCreatedAtForExpiry(t *testing.T) {
+	// Entries migrated from version 1 have no known age and count as expired
+	cache, err := OpenCacheAt(openFixture(t, "cache_v1"))
+	if err != nil {
+		t.Fatalf("failed to open: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	entry, err := cache.Get("search:puu")
+	if err != nil || entry == nil {
+		t.Fatalf("Get() = %+v, %v", entry, err)
+	}
+	if !entry.Expired(time.Hour, time.Now()) {
+		t.Error("expected migrated v1 entry to be expired")
+	}
+}
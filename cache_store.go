@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheStore is the key-value storage behind CachingFetcher.
+// Get returns nil (and no error) for a missing key.
+type CacheStore interface {
+	Get(key string) (*CacheEntry, error)
+	Set(key string, value []byte) error
+	Delete(key string) error
+	Clear() error
+	Close() error
+}
+
+// Cache backends selectable with -cache-backend.
+const (
+	backendSQLite = "sqlite"
+	backendMemory = "memory"
+	backendDir    = "dir"
+)
+
+var cacheBackends = []string{backendSQLite, backendMemory, backendDir}
+
+// OpenCacheStore opens the named cache backend at its default location.
+func OpenCacheStore(backend string) (CacheStore, error) {
+	// Return nil rather than a typed nil pointer on error, so callers can
+	// compare the interface with nil.
+	switch backend {
+	case backendSQLite, "":
+		c, err := OpenCache()
+		if err != nil {
+			return nil, err
+		}
+		return c, nil
+	case backendMemory:
+		return NewMemoryCache(), nil
+	case backendDir:
+		dir, err := defaultCacheDir()
+		if err != nil {
+			return nil, err
+		}
+		c, err := OpenDirCache(filepath.Join(dir, "entries"))
+		if err != nil {
+			return nil, err
+		}
+		return c, nil
+	}
+	return nil, fmt.Errorf("unknown cache backend %q (want %s)", backend, strings.Join(cacheBackends, ", "))
+}
+
+// MemoryCache is a CacheStore that keeps entries in memory for the life
+// of the process. It is safe for concurrent use.
+type MemoryCache struct {
+	mu      sync.Mutex
+	entries map[string]CacheEntry
+	now     func() time.Time
+}
+
+// NewMemoryCache creates an empty in-memory cache.
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: make(map[string]CacheEntry), now: time.Now}
+}
+
+// Get retrieves a copy of a value and marks it as recently used.
+// Returns nil if not found.
+func (c *MemoryCache) Get(key string) (*CacheEntry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, nil
+	}
+	result := entry
+	result.Value = append([]byte(nil), entry.Value...)
+
+	entry.AccessedAt = c.now()
+	c.entries[key] = entry
+	return &result, nil
+}
+
+// Set stores a copy of value.
+func (c *MemoryCache) Set(key string, value []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	c.entries[key] = CacheEntry{
+		Value:      append([]byte(nil), value...),
+		CreatedAt:  now,
+		AccessedAt: now,
+	}
+	return nil
+}
+
+// Delete removes a key from the cache.
+func (c *MemoryCache) Delete(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+	return nil
+}
+
+// Clear removes all entries from the cache.
+func (c *MemoryCache) Clear() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]CacheEntry)
+	return nil
+}
+
+// Close discards the cache contents.
+func (c *MemoryCache) Close() error {
+	return c.Clear()
+}
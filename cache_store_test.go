@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// cacheStores opens an empty cache of every backend.
+func cacheStores(t *testing.T) map[string]CacheStore {
+	t.Helper()
+	dir, err := OpenDirCache(filepath.Join(t.TempDir(), "entries"))
+	if err != nil {
+		t.Fatalf("OpenDirCache() error: %v", err)
+	}
+	return map[string]CacheStore{
+		backendSQLite: openTestCache(t),
+		backendMemory: NewMemoryCache(),
+		backendDir:    dir,
+	}
+}
+
+func TestCacheStore_Contract(t *testing.T) {
+	for name, store := range cacheStores(t) {
+		t.Run(name, func(t *testing.T) {
+			entry, err := store.Get("search:puu")
+			if err != nil || entry != nil {
+				t.Fatalf("Get() on empty cache = %v, %v; want nil, nil", entry, err)
+			}
+
+			before := time.Now().Add(-time.Second)
+			if err := store.Set("search:puu", []byte(`{"words":[]}`)); err != nil {
+				t.Fatalf("Set() error: %v", err)
+			}
+			if err := store.Set("search:Puu", []byte(`{"upper":true}`)); err != nil {
+				t.Fatalf("Set() error: %v", err)
+			}
+
+			entry, err = store.Get("search:puu")
+			if err != nil || entry == nil {
+				t.Fatalf("Get() = %v, %v after Set", entry, err)
+			}
+			if string(entry.Value) != `{"words":[]}` {
+				t.Errorf("Value = %s", entry.Value)
+			}
+			if entry.CreatedAt.Before(before) {
+				t.Errorf("CreatedAt = %v, want after %v", entry.CreatedAt, before)
+			}
+			if entry, _ := store.Get("search:Puu"); entry == nil || string(entry.Value) != `{"upper":true}` {
+				t.Errorf("keys differing only in case collided: %v", entry)
+			}
+
+			if err := store.Set("search:puu", []byte("v2")); err != nil {
+				t.Fatalf("Set() overwrite error: %v", err)
+			}
+			if entry, _ := store.Get("search:puu"); entry == nil || string(entry.Value) != "v2" {
+				t.Errorf("Get() after overwrite = %v", entry)
+			}
+
+			if err := store.Delete("search:puu"); err != nil {
+				t.Fatalf("Delete() error: %v", err)
+			}
+			if err := store.Delete("search:missing"); err != nil {
+				t.Errorf("Delete() of missing key error: %v", err)
+			}
+			if entry, _ := store.Get("search:puu"); entry != nil {
+				t.Errorf("Get() after Delete = %v", entry)
+			}
+
+			if err := store.Clear(); err != nil {
+				t.Fatalf("Clear() error: %v", err)
+			}
+			if entry, _ := store.Get("search:Puu"); entry != nil {
+				t.Errorf("Get() after Clear = %v", entry)
+			}
+		})
+	}
+}
+
+func TestCacheStore_CachingFetcher(t *testing.T) {
+	for name, store := range cacheStores(t) {
+		t.Run(name, func(t *testing.T) {
+			mock := &MockFetcher{SearchResponse: []byte(`{"words":[{"wordId":1,"wordValue":"puu","lang":"est"}]}`)}
+			fetcher := NewCachingFetcher(mock, store, CacheOptions{})
+
+			for i := 0; i < 2; i++ {
+				if _, err := fetcher.Search(context.Background(), "puu"); err != nil {
+					t.Fatalf("Search() error: %v", err)
+				}
+			}
+			if len(mock.SearchCalls) != 1 {
+				t.Errorf("upstream called %d times, want 1", len(mock.SearchCalls))
+			}
+		})
+	}
+}
+
+func TestMemoryCache_ReturnsCopies(t *testing.T) {
+	c := NewMemoryCache()
+	value := []byte("puu")
+	_ = c.Set("k", value)
+	value[0] = 'X'
+
+	entry, _ := c.Get("k")
+	entry.Value[1] = 'X'
+
+	if again, _ := c.Get("k"); string(again.Value) != "puu" {
+		t.Errorf("stored value changed through caller's slice: %q", again.Value)
+	}
+}
+
+func TestCacheFileName(t *testing.T) {
+	tests := map[string]string{
+		"details:123":  "details%3A123",
+		"search:Puu":   "search%3A%50uu",
+		"search:../x":  "search%3A%2E%2E%2Fx",
+		"search:jõgi":  "search%3Aj%C3%B5gi",
+		"paradigm:4_a": "paradigm%3A4_a",
+	}
+	for key, want := range tests {
+		if got := cacheFileName(key); got != want {
+			t.Errorf("cacheFileName(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestDirCache_PersistsAcrossOpens(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "entries")
+	c, err := OpenDirCache(dir)
+	if err != nil {
+		t.Fatalf("OpenDirCache() error: %v", err)
+	}
+	created := time.Now().Add(-time.Hour).Truncate(time.Second)
+	c.now = func() time.Time { return created }
+	if err := c.Set("search:puu", []byte("data")); err != nil {
+		t.Fatalf("Set() error: %v", err)
+	}
+
+	reopened, err := OpenDirCache(dir)
+	if err != nil {
+		t.Fatalf("OpenDirCache() error: %v", err)
+	}
+	entry, err := reopened.Get("search:puu")
+	if err != nil || entry == nil {
+		t.Fatalf("Get() = %v, %v", entry, err)
+	}
+	if !entry.CreatedAt.Equal(created) {
+		t.Errorf("CreatedAt = %v, want %v", entry.CreatedAt, created)
+	}
+
+	files, _ := os.ReadDir(dir)
+	if len(files) != 1 || files[0].Name() != "search%3Apuu" {
+		t.Errorf("files = %v, want just search%%3Apuu (no temporary files)", files)
+	}
+}
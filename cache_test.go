@@ -1,8 +1,12 @@
 package main
 
 import (
+	"bytes"
+	"database/sql"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -126,3 +130,421 @@ func TestCache(t *testing.T) {
 		}
 	})
 }
+
+func TestCacheEntry_Expired(t *testing.T) {
+	created := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	entry := &CacheEntry{Value: []byte("v"), CreatedAt: created}
+
+	tests := []struct {
+		name string
+		ttl  time.Duration
+		age  time.Duration
+		want bool
+	}{
+		{"within ttl", time.Hour, 30 * time.Minute, false},
+		{"past ttl", time.Hour, 2 * time.Hour, true},
+		{"zero ttl never expires", 0, 1000 * time.Hour, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := entry.Expired(tt.ttl, created.Add(tt.age)); got != tt.want {
+				t.Errorf("Expired(%v) at age %v = %v, want %v", tt.ttl, tt.age, got, tt.want)
+			}
+		})
+	}
+}
+
+// openTestCache opens a cache in a fresh temporary directory.
+func openTestCache(t *testing.T) *Cache {
+	t.Helper()
+	cache, err := OpenCacheAt(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to open cache: %v", err)
+	}
+	t.Cleanup(func() { _ = cache.Close() })
+	return cache
+}
+
+// setCreatedAt backdates an entry for tests that depend on age.
+func setCreatedAt(t *testing.T, c *Cache, key string, createdAt time.Time) {
+	t.Helper()
+	if _, err := c.db.Exec("UPDATE cache SET created_at = ? WHERE key = ?", createdAt.Unix(), key); err != nil {
+		t.Fatalf("failed to backdate %q: %v", key, err)
+	}
+}
+
+func TestCache_Queries(t *testing.T) {
+	cache := openTestCache(t)
+
+	entries := map[string]string{
+		"search:puu":    `{"words":[]}`,
+		"search:maja":   `{"words":[]}`,
+		"details:1":     `{"wordClass":"noun"}`,
+		"paradigm:1":    `[]`,
+		"paradigm:2":    `[1,2,3]`,
+		"unprefixedkey": `x`,
+	}
+	for k, v := range entries {
+		if err := cache.Set(k, []byte(v)); err != nil {
+			t.Fatalf("failed to set %q: %v", k, err)
+		}
+	}
+
+	t.Run("stats groups by prefix", func(t *testing.T) {
+		stats, err := cache.Stats()
+		if err != nil {
+			t.Fatalf("Stats() error: %v", err)
+		}
+
+		want := []CachePrefixStats{
+			{Prefix: "", Entries: 1, Bytes: 1, StoredBytes: 1},
+			{Prefix: "details:", Entries: 1, Bytes: 20, StoredBytes: 20},
+			{Prefix: "paradigm:", Entries: 2, Bytes: 9, StoredBytes: 9},
+			{Prefix: "search:", Entries: 2, Bytes: 24, StoredBytes: 24},
+		}
+		if len(stats) != len(want) {
+			t.Fatalf("Stats() = %+v, want %+v", stats, want)
+		}
+		for i := range want {
+			if stats[i] != want[i] {
+				t.Errorf("Stats()[%d] = %+v, want %+v", i, stats[i], want[i])
+			}
+		}
+	})
+
+	t.Run("list with glob", func(t *testing.T) {
+		keys, err := cache.List("paradigm:*")
+		if err != nil {
+			t.Fatalf("List() error: %v", err)
+		}
+		if len(keys) != 2 || keys[0].Key != "paradigm:1" || keys[1].Key != "paradigm:2" {
+			t.Errorf("List(paradigm:*) = %+v", keys)
+		}
+		if keys[1].Size != 7 {
+			t.Errorf("expected size 7 for paradigm:2, got %d", keys[1].Size)
+		}
+
+		all, err := cache.List("")
+		if err != nil {
+			t.Fatalf("List() error: %v", err)
+		}
+		if len(all) != len(entries) {
+			t.Errorf("expected %d keys, got %d", len(entries), len(all))
+		}
+	})
+
+	t.Run("delete matching", func(t *testing.T) {
+		n, err := cache.DeleteMatching("search:*")
+		if err != nil {
+			t.Fatalf("DeleteMatching() error: %v", err)
+		}
+		if n != 2 {
+			t.Errorf("expected 2 deleted, got %d", n)
+		}
+		if entry, _ := cache.Get("search:puu"); entry != nil {
+			t.Error("expected search:puu to be deleted")
+		}
+		if entry, _ := cache.Get("details:1"); entry == nil {
+			t.Error("expected details:1 to remain")
+		}
+	})
+
+	t.Run("delete older than", func(t *testing.T) {
+		setCreatedAt(t, cache, "paradigm:1", time.Now().Add(-48*time.Hour))
+
+		n, err := cache.DeleteOlderThan(time.Now().Add(-24 * time.Hour))
+		if err != nil {
+			t.Fatalf("DeleteOlderThan() error: %v", err)
+		}
+		if n != 1 {
+			t.Errorf("expected 1 deleted, got %d", n)
+		}
+		if entry, _ := cache.Get("paradigm:1"); entry != nil {
+			t.Error("expected paradigm:1 to be pruned")
+		}
+		if entry, _ := cache.Get("paradigm:2"); entry == nil {
+			t.Error("expected paradigm:2 to remain")
+		}
+	})
+}
+
+func TestCache_LRUEviction(t *testing.T) {
+	// A controllable clock, so access order doesn't depend on timing
+	clock := time.Unix(1_700_000_000, 0)
+	tick := func(c *Cache) {
+		clock = clock.Add(accessResolution)
+		c.now = func() time.Time { return clock }
+	}
+
+	t.Run("max entries", func(t *testing.T) {
+		cache := openTestCache(t)
+		cache.SetLimits(CacheLimits{MaxEntries: 2})
+
+		for _, k := range []string{"a", "b"} {
+			tick(cache)
+			if err := cache.Set(k, []byte("v")); err != nil {
+				t.Fatalf("failed to set %q: %v", k, err)
+			}
+		}
+
+		// Reading "a" makes "b" the least recently used
+		tick(cache)
+		if _, err := cache.Get("a"); err != nil {
+			t.Fatalf("failed to get: %v", err)
+		}
+
+		tick(cache)
+		if err := cache.Set("c", []byte("v")); err != nil {
+			t.Fatalf("failed to set c: %v", err)
+		}
+
+		keys, err := cache.List("")
+		if err != nil {
+			t.Fatalf("List() error: %v", err)
+		}
+		if len(keys) != 2 || keys[0].Key != "a" || keys[1].Key != "c" {
+			t.Errorf("expected [a c] after eviction, got %+v", keys)
+		}
+	})
+
+	t.Run("max bytes", func(t *testing.T) {
+		cache := openTestCache(t)
+		cache.SetLimits(CacheLimits{MaxBytes: 25})
+
+		for _, k := range []string{"a", "b", "c"} {
+			tick(cache)
+			if err := cache.Set(k, make([]byte, 10)); err != nil {
+				t.Fatalf("failed to set %q: %v", k, err)
+			}
+		}
+
+		if entry, _ := cache.Get("a"); entry != nil {
+			t.Error("expected oldest entry a to be evicted")
+		}
+		for _, k := range []string{"b", "c"} {
+			if entry, _ := cache.Get(k); entry == nil {
+				t.Errorf("expected %q to remain", k)
+			}
+		}
+	})
+
+	t.Run("no limits keeps everything", func(t *testing.T) {
+		cache := openTestCache(t)
+		for i := 0; i < 50; i++ {
+			if err := cache.Set(fmt.Sprintf("k%d", i), []byte("v")); err != nil {
+				t.Fatalf("failed to set: %v", err)
+			}
+		}
+		keys, err := cache.List("")
+		if err != nil {
+			t.Fatalf("List() error: %v", err)
+		}
+		if len(keys) != 50 {
+			t.Errorf("expected 50 entries, got %d", len(keys))
+		}
+	})
+}
+
+func TestCache_MigratesPreLRUSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old.db")
+
+	// Create a cache as written before accessed_at existed
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	_, err = db.Exec(`
+		CREATE TABLE cache (key TEXT PRIMARY KEY, value BLOB, created_at INTEGER);
+		INSERT INTO cache VALUES ('search:puu', 'old value', 1700000000);
+	`)
+	if err != nil {
+		t.Fatalf("failed to create old schema: %v", err)
+	}
+	_ = db.Close()
+
+	cache, err := OpenCacheAt(path)
+	if err != nil {
+		t.Fatalf("failed to open migrated cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	keys, err := cache.List("")
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if len(keys) != 1 {
+		t.Fatalf("expected existing entry to survive migration, got %+v", keys)
+	}
+	if !keys[0].AccessedAt.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("expected accessed_at backfilled from created_at, got %v", keys[0].AccessedAt)
+	}
+
+	entry, err := cache.Get("search:puu")
+	if err != nil || entry == nil || string(entry.Value) != "old value" {
+		t.Errorf("expected old value after migration, got %+v, %v", entry, err)
+	}
+}
+
+func TestCache_Compression(t *testing.T) {
+	cache := openTestCache(t)
+
+	// Paradigm-like JSON: large and repetitive
+	var sb strings.Builder
+	sb.WriteString("[")
+	for i := 0; i < 100; i++ {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		fmt.Fprintf(&sb, `{"value":"tegema%d","morphCode":"IndPrSg1"}`, i)
+	}
+	sb.WriteString("]")
+	large := []byte(sb.String())
+	small := []byte(`{"words":[]}`)
+
+	if err := cache.Set("paradigm:1", large); err != nil {
+		t.Fatalf("failed to set large value: %v", err)
+	}
+	if err := cache.Set("search:puu", small); err != nil {
+		t.Fatalf("failed to set small value: %v", err)
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		for key, want := range map[string][]byte{"paradigm:1": large, "search:puu": small} {
+			entry, err := cache.Get(key)
+			if err != nil || entry == nil {
+				t.Fatalf("Get(%q) = %+v, %v", key, entry, err)
+			}
+			if !bytes.Equal(entry.Value, want) {
+				t.Errorf("Get(%q) returned different data", key)
+			}
+		}
+	})
+
+	t.Run("large values stored compressed", func(t *testing.T) {
+		var encoding string
+		var storedLen int
+		err := cache.db.QueryRow("SELECT encoding, length(value) FROM cache WHERE key = 'paradigm:1'").Scan(&encoding, &storedLen)
+		if err != nil {
+			t.Fatalf("query error: %v", err)
+		}
+		if encoding != encodingGzip {
+			t.Errorf("expected gzip encoding, got %q", encoding)
+		}
+		if storedLen >= len(large)/2 {
+			t.Errorf("expected substantial compression, stored %d of %d bytes", storedLen, len(large))
+		}
+	})
+
+	t.Run("small values stored raw", func(t *testing.T) {
+		var encoding string
+		if err := cache.db.QueryRow("SELECT encoding FROM cache WHERE key = 'search:puu'").Scan(&encoding); err != nil {
+			t.Fatalf("query error: %v", err)
+		}
+		if encoding != encodingRaw {
+			t.Errorf("expected raw encoding, got %q", encoding)
+		}
+	})
+
+	t.Run("stats report compression ratio", func(t *testing.T) {
+		stats, err := cache.Stats()
+		if err != nil {
+			t.Fatalf("Stats() error: %v", err)
+		}
+		for _, s := range stats {
+			switch s.Prefix {
+			case "paradigm:":
+				if s.Bytes != int64(len(large)) || s.CompressionRatio() < 2 {
+					t.Errorf("unexpected paradigm stats: %+v (ratio %.1f)", s, s.CompressionRatio())
+				}
+			case "search:":
+				if s.CompressionRatio() != 1 {
+					t.Errorf("expected ratio 1 for raw values, got %.1f", s.CompressionRatio())
+				}
+			}
+		}
+	})
+
+	t.Run("list reports uncompressed size", func(t *testing.T) {
+		keys, err := cache.List("paradigm:*")
+		if err != nil {
+			t.Fatalf("List() error: %v", err)
+		}
+		if len(keys) != 1 || keys[0].Size != int64(len(large)) {
+			t.Errorf("List() = %+v, want size %d", keys, len(large))
+		}
+	})
+
+	t.Run("uncompressed legacy rows stay readable", func(t *testing.T) {
+		_, err := cache.db.Exec("INSERT INTO cache (key, value, created_at) VALUES ('details:9', ?, 1700000000)", large)
+		if err != nil {
+			t.Fatalf("insert error: %v", err)
+		}
+		entry, err := cache.Get("details:9")
+		if err != nil || entry == nil || !bytes.Equal(entry.Value, large) {
+			t.Errorf("expected legacy row readable, got %v", err)
+		}
+	})
+}
+
+func TestDecodeValue_UnknownEncoding(t *testing.T) {
+	if _, err := decodeValue([]byte("x"), "zstd"); err == nil {
+		t.Error("expected error for unknown encoding")
+	}
+}
+
+func TestCache_GetRecordsAccessLazily(t *testing.T) {
+	cache := openTestCache(t)
+	clock := time.Unix(1_700_000_000, 0)
+	cache.now = func() time.Time { return clock }
+	if err := cache.Set("k", []byte("v")); err != nil {
+		t.Fatalf("Set() error: %v", err)
+	}
+
+	accessedAt := func() time.Time {
+		t.Helper()
+		keys, err := cache.List("k")
+		if err != nil || len(keys) != 1 {
+			t.Fatalf("List() = %v, %v", keys, err)
+		}
+		return keys[0].AccessedAt
+	}
+
+	// A recent access is not worth a write
+	clock = clock.Add(accessResolution / 2)
+	if _, err := cache.Get("k"); err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	if got := accessedAt(); !got.Equal(time.Unix(1_700_000_000, 0)) {
+		t.Errorf("accessed_at = %v, want it unchanged", got)
+	}
+
+	clock = clock.Add(accessResolution)
+	if _, err := cache.Get("k"); err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	if got := accessedAt(); !got.Equal(clock) {
+		t.Errorf("accessed_at = %v, want %v", got, clock)
+	}
+}
+
+func TestCache_GetSurvivesFailedAccessUpdate(t *testing.T) {
+	cache := openTestCache(t)
+	if err := cache.Set("k", []byte("v")); err != nil {
+		t.Fatalf("Set() error: %v", err)
+	}
+	// Stand in for a write lock held past the busy timeout
+	if _, err := cache.db.Exec(`CREATE TRIGGER no_updates BEFORE UPDATE ON cache
+		BEGIN SELECT RAISE(ABORT, 'database is locked'); END`); err != nil {
+		t.Fatal(err)
+	}
+	cache.now = func() time.Time { return time.Now().Add(2 * accessResolution) }
+
+	entry, err := cache.Get("k")
+	if err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	if entry == nil || string(entry.Value) != "v" {
+		t.Errorf("Get() = %v, want the cached value", entry)
+	}
+}
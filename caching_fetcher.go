@@ -1,73 +1,156 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"os"
+	"time"
 )
 
+// ErrNotCached is returned in offline mode when an entry is not in the cache.
+var ErrNotCached = errors.New("not cached (offline mode)")
+
+// CacheOptions controls how CachingFetcher reads and expires entries.
+// A zero TTL means entries of that kind never expire.
+type CacheOptions struct {
+	Refresh      bool // If true, bypass cache reads (but still write)
+	Offline      bool // If true, never call upstream; serve any cached entry
+	StaleIfError bool // If true, serve expired entries when upstream fails
+	SearchTTL    time.Duration
+	DetailsTTL   time.Duration
+	ParadigmTTL  time.Duration
+	NotFoundTTL  time.Duration // For searches with no Estonian words
+}
+
+// DefaultCacheOptions are used by the CLI unless overridden by flags.
+// Search results change more often than the entries they point to, as
+// new homonyms are added, so they expire sooner. Searches that found
+// nothing are usually typos, but may be words added later, so they are
+// remembered only briefly.
+var DefaultCacheOptions = CacheOptions{
+	StaleIfError: true,
+	SearchTTL:    7 * 24 * time.Hour,
+	DetailsTTL:   30 * 24 * time.Hour,
+	ParadigmTTL:  30 * 24 * time.Hour,
+	NotFoundTTL:  24 * time.Hour,
+}
+
 // CachingFetcher wraps a Fetcher with a cache layer.
-// On cache hit, returns cached data. On miss, fetches from upstream and caches.
+// On cache hit, returns cached data. On miss or expiry, fetches from upstream
+// and caches. Concurrent misses for the same key share a single upstream call.
 type CachingFetcher struct {
 	upstream Fetcher
-	cache    *Cache
-	refresh  bool // If true, bypass cache reads (but still write)
+	cache    CacheStore
+	opts     CacheOptions
+	now      func() time.Time
+	stderr   io.Writer // Receives warnings about stale data
+	inflight flightGroup
 }
 
 // NewCachingFetcher creates a caching fetcher.
 // If cache is nil, it behaves like the upstream fetcher.
-// If refresh is true, it bypasses cache reads but still updates the cache.
-func NewCachingFetcher(upstream Fetcher, cache *Cache, refresh bool) *CachingFetcher {
+func NewCachingFetcher(upstream Fetcher, cache CacheStore, opts CacheOptions) *CachingFetcher {
 	return &CachingFetcher{
 		upstream: upstream,
 		cache:    cache,
-		refresh:  refresh,
+		opts:     opts,
+		now:      time.Now,
+		stderr:   os.Stderr,
 	}
 }
 
-func (f *CachingFetcher) Search(word string) ([]byte, error) {
-	return f.cachedFetch("search:"+word, func() ([]byte, error) {
-		return f.upstream.Search(word)
+func (f *CachingFetcher) Search(ctx context.Context, word string) ([]byte, error) {
+	return f.cachedFetch(ctx, "search:"+word, f.searchTTL, func(ctx context.Context) ([]byte, error) {
+		return f.upstream.Search(ctx, word)
 	})
 }
 
-func (f *CachingFetcher) WordDetails(wordID int64) ([]byte, error) {
-	return f.cachedFetch(fmt.Sprintf("details:%d", wordID), func() ([]byte, error) {
-		return f.upstream.WordDetails(wordID)
+func (f *CachingFetcher) WordDetails(ctx context.Context, wordID int64) ([]byte, error) {
+	return f.cachedFetch(ctx, fmt.Sprintf("details:%d", wordID), fixedTTL(f.opts.DetailsTTL), func(ctx context.Context) ([]byte, error) {
+		return f.upstream.WordDetails(ctx, wordID)
 	})
 }
 
-func (f *CachingFetcher) ParadigmDetails(wordID int64) ([]byte, error) {
-	return f.cachedFetch(fmt.Sprintf("paradigm:%d", wordID), func() ([]byte, error) {
-		return f.upstream.ParadigmDetails(wordID)
+func (f *CachingFetcher) ParadigmDetails(ctx context.Context, wordID int64) ([]byte, error) {
+	return f.cachedFetch(ctx, fmt.Sprintf("paradigm:%d", wordID), fixedTTL(f.opts.ParadigmTTL), func(ctx context.Context) ([]byte, error) {
+		return f.upstream.ParadigmDetails(ctx, wordID)
 	})
 }
 
-func (f *CachingFetcher) cachedFetch(key string, fetch func() ([]byte, error)) ([]byte, error) {
-	// No cache? Just fetch.
-	if f.cache == nil {
-		return fetch()
+// searchTTL picks the TTL for a cached search result: the short negative
+// TTL if it has no Estonian words, the regular search TTL otherwise.
+func (f *CachingFetcher) searchTTL(value []byte) time.Duration {
+	if isNotFoundSearch(value) {
+		return f.opts.NotFoundTTL
+	}
+	return f.opts.SearchTTL
+}
+
+// isNotFoundSearch reports whether a raw search response contains no
+// Estonian words, i.e. the lookup would end in "word not found".
+func isNotFoundSearch(data []byte) bool {
+	result, err := ParseSearchResult(data)
+	if err != nil {
+		return false
 	}
+	return len(FilterEstonianWords(result.Words)) == 0
+}
+
+func fixedTTL(ttl time.Duration) func([]byte) time.Duration {
+	return func([]byte) time.Duration { return ttl }
+}
 
-	// Check cache (unless refresh mode)
-	if !f.refresh {
+func (f *CachingFetcher) cachedFetch(ctx context.Context, key string, ttl func(value []byte) time.Duration, fetch func(context.Context) ([]byte, error)) ([]byte, error) {
+	// Check cache (unless there is none, or refresh mode).
+	// Offline, any entry will do regardless of age.
+	var stale *CacheEntry
+	if f.cache != nil && (!f.opts.Refresh || f.opts.Offline) {
 		entry, err := f.cache.Get(key)
 		if err != nil {
 			log.Printf("cache get error for %q: %v", key, err)
 		} else if entry != nil {
-			return entry.Value, nil
+			if f.opts.Offline || !entry.Expired(ttl(entry.Value), f.now()) {
+				return entry.Value, nil
+			}
+			stale = entry
 		}
 	}
 
-	// Fetch from upstream
-	data, err := fetch()
-	if err != nil {
-		return nil, err
+	if f.opts.Offline {
+		return nil, fmt.Errorf("%w: %s", ErrNotCached, key)
 	}
 
-	// Store in cache (best-effort; log errors)
-	if err := f.cache.Set(key, data); err != nil {
-		log.Printf("cache set error for %q: %v", key, err)
+	// Fetch from upstream, coalescing concurrent requests for the same key
+	data, err := f.inflight.Do(ctx, key, func(ctx context.Context) ([]byte, error) {
+		data, err := fetch(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		// Store in cache (best-effort; log errors)
+		if f.cache != nil {
+			if err := f.cache.Set(key, data); err != nil {
+				log.Printf("cache set error for %q: %v", key, err)
+			}
+		}
+
+		return data, nil
+	})
+	if err == nil || !f.opts.StaleIfError || errors.Is(err, context.Canceled) {
+		return data, err
 	}
 
-	return data, nil
+	// Upstream failed: fall back to whatever we have cached, however old
+	if stale == nil && f.cache != nil {
+		stale, _ = f.cache.Get(key)
+	}
+	if stale == nil {
+		return nil, err
+	}
+	_, _ = fmt.Fprintf(f.stderr, "warning: %v; using cached %s from %s\n",
+		err, key, stale.CreatedAt.Format("2006-01-02 15:04"))
+	return stale.Value, nil
 }
@@ -1,13 +1,23 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 // MockFetcher records calls and returns canned responses.
+// It is safe for concurrent use.
 type MockFetcher struct {
+	mu sync.Mutex
+
 	SearchCalls   []string
 	DetailsCalls  []int64
 	ParadigmCalls []int64
@@ -15,20 +25,36 @@ type MockFetcher struct {
 	SearchResponse   []byte
 	DetailsResponse  []byte
 	ParadigmResponse []byte
+	Err              error // If set, every call fails with it
 }
 
-func (m *MockFetcher) Search(word string) ([]byte, error) {
+func (m *MockFetcher) Search(ctx context.Context, word string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.SearchCalls = append(m.SearchCalls, word)
+	if m.Err != nil {
+		return nil, m.Err
+	}
 	return m.SearchResponse, nil
 }
 
-func (m *MockFetcher) WordDetails(wordID int64) ([]byte, error) {
+func (m *MockFetcher) WordDetails(ctx context.Context, wordID int64) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.DetailsCalls = append(m.DetailsCalls, wordID)
+	if m.Err != nil {
+		return nil, m.Err
+	}
 	return m.DetailsResponse, nil
 }
 
-func (m *MockFetcher) ParadigmDetails(wordID int64) ([]byte, error) {
+func (m *MockFetcher) ParadigmDetails(ctx context.Context, wordID int64) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.ParadigmCalls = append(m.ParadigmCalls, wordID)
+	if m.Err != nil {
+		return nil, m.Err
+	}
 	return m.ParadigmResponse, nil
 }
 
@@ -51,11 +77,12 @@ func TestCachingFetcher(t *testing.T) {
 		ParadigmResponse: []byte(`[]`),
 	}
 
-	fetcher := NewCachingFetcher(mock, cache, false)
+	ctx := context.Background()
+	fetcher := NewCachingFetcher(mock, cache, CacheOptions{})
 
 	t.Run("caches search results", func(t *testing.T) {
 		// First call — should hit upstream
-		data1, err := fetcher.Search("puu")
+		data1, err := fetcher.Search(ctx, "puu")
 		if err != nil {
 			t.Fatalf("fetcher.Search() error: %v", err)
 		}
@@ -67,7 +94,7 @@ func TestCachingFetcher(t *testing.T) {
 		}
 
 		// Second call — should hit cache
-		data2, err := fetcher.Search("puu")
+		data2, err := fetcher.Search(ctx, "puu")
 		if err != nil {
 			t.Fatalf("fetcher.Search() error: %v", err)
 		}
@@ -80,10 +107,10 @@ func TestCachingFetcher(t *testing.T) {
 	})
 
 	t.Run("caches word details", func(t *testing.T) {
-		if _, err := fetcher.WordDetails(123); err != nil {
+		if _, err := fetcher.WordDetails(ctx, 123); err != nil {
 			t.Fatalf("fetcher.WordDetails() error: %v", err)
 		}
-		if _, err := fetcher.WordDetails(123); err != nil {
+		if _, err := fetcher.WordDetails(ctx, 123); err != nil {
 			t.Fatalf("fetcher.WordDetails() error: %v", err)
 		}
 		if len(mock.DetailsCalls) != 1 {
@@ -92,10 +119,10 @@ func TestCachingFetcher(t *testing.T) {
 	})
 
 	t.Run("caches paradigm details", func(t *testing.T) {
-		if _, err := fetcher.ParadigmDetails(456); err != nil {
+		if _, err := fetcher.ParadigmDetails(ctx, 456); err != nil {
 			t.Fatalf("fetcher.ParadigmDetails() error: %v", err)
 		}
-		if _, err := fetcher.ParadigmDetails(456); err != nil {
+		if _, err := fetcher.ParadigmDetails(ctx, 456); err != nil {
 			t.Fatalf("fetcher.ParadigmDetails() error: %v", err)
 		}
 		if len(mock.ParadigmCalls) != 1 {
@@ -104,7 +131,7 @@ func TestCachingFetcher(t *testing.T) {
 	})
 
 	t.Run("refresh bypasses cache read", func(t *testing.T) {
-		refreshFetcher := NewCachingFetcher(mock, cache, true)
+		refreshFetcher := NewCachingFetcher(mock, cache, CacheOptions{Refresh: true})
 
 		// Pre-populate cache
 		if err := cache.Set("search:maja", []byte(`cached`)); err != nil {
@@ -113,7 +140,7 @@ func TestCachingFetcher(t *testing.T) {
 
 		// With refresh=true, should still call upstream
 		callsBefore := len(mock.SearchCalls)
-		data, err := refreshFetcher.Search("maja")
+		data, err := refreshFetcher.Search(ctx, "maja")
 		if err != nil {
 			t.Fatalf("refreshFetcher.Search() error: %v", err)
 		}
@@ -139,8 +166,8 @@ func TestCachingFetcher(t *testing.T) {
 	})
 
 	t.Run("works without cache", func(t *testing.T) {
-		noCacheFetcher := NewCachingFetcher(mock, nil, false)
-		data, err := noCacheFetcher.Search("test")
+		noCacheFetcher := NewCachingFetcher(mock, nil, CacheOptions{})
+		data, err := noCacheFetcher.Search(ctx, "test")
 		if err != nil {
 			t.Errorf("unexpected error: %v", err)
 		}
@@ -149,3 +176,347 @@ func TestCachingFetcher(t *testing.T) {
 		}
 	})
 }
+
+// blockingFetcher counts searches and blocks each one until released.
+type blockingFetcher struct {
+	MockFetcher
+	release chan struct{}
+	calls   atomic.Int32
+}
+
+func (f *blockingFetcher) Search(ctx context.Context, word string) ([]byte, error) {
+	f.calls.Add(1)
+	<-f.release
+	return []byte(`{"words":[]}`), nil
+}
+
+func TestCachingFetcher_CoalescesConcurrentMisses(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "sonaveeb-caching-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	cache, err := OpenCacheAt(filepath.Join(tmpDir, "test.db"))
+	if err != nil {
+		t.Fatalf("failed to open cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	upstream := &blockingFetcher{release: make(chan struct{})}
+	fetcher := NewCachingFetcher(upstream, cache, CacheOptions{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			data, err := fetcher.Search(context.Background(), "puu")
+			if err != nil {
+				t.Errorf("fetcher.Search() error: %v", err)
+			}
+			if string(data) != `{"words":[]}` {
+				t.Errorf("unexpected response: %s", data)
+			}
+		}()
+	}
+
+	waitForWaiters(t, &fetcher.inflight, "search:puu", 5)
+	close(upstream.release)
+	wg.Wait()
+
+	if n := upstream.calls.Load(); n != 1 {
+		t.Errorf("expected 1 upstream call for concurrent misses, got %d", n)
+	}
+}
+
+func TestCachingFetcher_TTL(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "sonaveeb-caching-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	cache, err := OpenCacheAt(filepath.Join(tmpDir, "test.db"))
+	if err != nil {
+		t.Fatalf("failed to open cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	mock := &MockFetcher{
+		SearchResponse:   []byte(`{"words":[{"wordId":1,"wordValue":"puu","lang":"est"}]}`),
+		DetailsResponse:  []byte(`{"wordClass":"noun"}`),
+		ParadigmResponse: []byte(`[]`),
+	}
+
+	ctx := context.Background()
+	fetcher := NewCachingFetcher(mock, cache, CacheOptions{
+		SearchTTL:   time.Hour,
+		DetailsTTL:  24 * time.Hour,
+		ParadigmTTL: 0,
+	})
+
+	// Populate all three entries
+	if _, err := fetcher.Search(ctx, "puu"); err != nil {
+		t.Fatalf("fetcher.Search() error: %v", err)
+	}
+	if _, err := fetcher.WordDetails(ctx, 1); err != nil {
+		t.Fatalf("fetcher.WordDetails() error: %v", err)
+	}
+	if _, err := fetcher.ParadigmDetails(ctx, 1); err != nil {
+		t.Fatalf("fetcher.ParadigmDetails() error: %v", err)
+	}
+
+	t.Run("fresh entries are served from cache", func(t *testing.T) {
+		fetcher.now = func() time.Time { return time.Now().Add(30 * time.Minute) }
+		if _, err := fetcher.Search(ctx, "puu"); err != nil {
+			t.Fatalf("fetcher.Search() error: %v", err)
+		}
+		if len(mock.SearchCalls) != 1 {
+			t.Errorf("expected cache hit, got %d upstream calls", len(mock.SearchCalls))
+		}
+	})
+
+	t.Run("expired entries are refetched per endpoint", func(t *testing.T) {
+		fetcher.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+
+		if _, err := fetcher.Search(ctx, "puu"); err != nil {
+			t.Fatalf("fetcher.Search() error: %v", err)
+		}
+		if len(mock.SearchCalls) != 2 {
+			t.Errorf("expected expired search to be refetched, got %d upstream calls", len(mock.SearchCalls))
+		}
+
+		if _, err := fetcher.WordDetails(ctx, 1); err != nil {
+			t.Fatalf("fetcher.WordDetails() error: %v", err)
+		}
+		if len(mock.DetailsCalls) != 1 {
+			t.Errorf("expected details still fresh, got %d upstream calls", len(mock.DetailsCalls))
+		}
+	})
+
+	t.Run("zero TTL never expires", func(t *testing.T) {
+		fetcher.now = func() time.Time { return time.Now().Add(10 * 365 * 24 * time.Hour) }
+		if _, err := fetcher.ParadigmDetails(ctx, 1); err != nil {
+			t.Fatalf("fetcher.ParadigmDetails() error: %v", err)
+		}
+		if len(mock.ParadigmCalls) != 1 {
+			t.Errorf("expected paradigm cache hit, got %d upstream calls", len(mock.ParadigmCalls))
+		}
+	})
+}
+
+func TestConfig_CacheOptions_MaxAgeOverrides(t *testing.T) {
+	cfg := Config{
+		Refresh:     true,
+		SearchTTL:   time.Hour,
+		DetailsTTL:  2 * time.Hour,
+		ParadigmTTL: 3 * time.Hour,
+	}
+
+	opts := cfg.CacheOptions()
+	if !opts.Refresh || opts.SearchTTL != time.Hour || opts.DetailsTTL != 2*time.Hour || opts.ParadigmTTL != 3*time.Hour {
+		t.Errorf("unexpected options without max-age: %+v", opts)
+	}
+
+	cfg.MaxAge = time.Minute
+	opts = cfg.CacheOptions()
+	if opts.SearchTTL != time.Minute || opts.DetailsTTL != time.Minute || opts.ParadigmTTL != time.Minute {
+		t.Errorf("expected max-age to override all TTLs, got %+v", opts)
+	}
+}
+
+func TestCachingFetcher_StaleAndOffline(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "sonaveeb-caching-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	cache, err := OpenCacheAt(filepath.Join(tmpDir, "test.db"))
+	if err != nil {
+		t.Fatalf("failed to open cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	if err := cache.Set("search:puu", []byte(`cached`)); err != nil {
+		t.Fatalf("cache.Set() error: %v", err)
+	}
+
+	ctx := context.Background()
+	later := func() time.Time { return time.Now().Add(48 * time.Hour) }
+	networkDown := errors.New("network error: connection refused")
+
+	t.Run("stale entry served when upstream fails", func(t *testing.T) {
+		mock := &MockFetcher{Err: networkDown}
+		var stderr bytes.Buffer
+		fetcher := NewCachingFetcher(mock, cache, CacheOptions{SearchTTL: time.Hour, StaleIfError: true})
+		fetcher.now = later
+		fetcher.stderr = &stderr
+
+		data, err := fetcher.Search(ctx, "puu")
+		if err != nil {
+			t.Fatalf("expected stale data, got error: %v", err)
+		}
+		if string(data) != "cached" {
+			t.Errorf("expected cached data, got %s", data)
+		}
+		if len(mock.SearchCalls) != 1 {
+			t.Errorf("expected upstream to be tried first, got %d calls", len(mock.SearchCalls))
+		}
+		if !strings.Contains(stderr.String(), "warning: network error") || !strings.Contains(stderr.String(), "search:puu") {
+			t.Errorf("expected stale warning on stderr, got %q", stderr.String())
+		}
+	})
+
+	t.Run("refresh falls back to cache when upstream fails", func(t *testing.T) {
+		mock := &MockFetcher{Err: networkDown}
+		fetcher := NewCachingFetcher(mock, cache, CacheOptions{Refresh: true, StaleIfError: true})
+		fetcher.stderr = &bytes.Buffer{}
+
+		data, err := fetcher.Search(ctx, "puu")
+		if err != nil || string(data) != "cached" {
+			t.Errorf("expected cached data, got %q, %v", data, err)
+		}
+	})
+
+	t.Run("upstream error returned without stale mode", func(t *testing.T) {
+		mock := &MockFetcher{Err: networkDown}
+		fetcher := NewCachingFetcher(mock, cache, CacheOptions{SearchTTL: time.Hour})
+		fetcher.now = later
+
+		if _, err := fetcher.Search(ctx, "puu"); !errors.Is(err, networkDown) {
+			t.Errorf("expected network error, got %v", err)
+		}
+	})
+
+	t.Run("upstream error returned when nothing cached", func(t *testing.T) {
+		mock := &MockFetcher{Err: networkDown}
+		fetcher := NewCachingFetcher(mock, cache, CacheOptions{StaleIfError: true})
+
+		if _, err := fetcher.Search(ctx, "maja"); !errors.Is(err, networkDown) {
+			t.Errorf("expected network error, got %v", err)
+		}
+	})
+
+	t.Run("offline serves expired entries without upstream", func(t *testing.T) {
+		mock := &MockFetcher{SearchResponse: []byte(`fresh`)}
+		fetcher := NewCachingFetcher(mock, cache, CacheOptions{Offline: true, SearchTTL: time.Hour})
+		fetcher.now = later
+
+		data, err := fetcher.Search(ctx, "puu")
+		if err != nil || string(data) != "cached" {
+			t.Errorf("expected cached data, got %q, %v", data, err)
+		}
+		if len(mock.SearchCalls) != 0 {
+			t.Errorf("expected no upstream calls offline, got %d", len(mock.SearchCalls))
+		}
+	})
+
+	t.Run("offline miss reports not cached", func(t *testing.T) {
+		mock := &MockFetcher{SearchResponse: []byte(`fresh`)}
+		fetcher := NewCachingFetcher(mock, cache, CacheOptions{Offline: true})
+
+		_, err := fetcher.Search(ctx, "maja")
+		if !errors.Is(err, ErrNotCached) {
+			t.Errorf("expected ErrNotCached, got %v", err)
+		}
+		if len(mock.SearchCalls) != 0 {
+			t.Errorf("expected no upstream calls offline, got %d", len(mock.SearchCalls))
+		}
+	})
+}
+
+func TestCachingFetcher_NegativeCaching(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "sonaveeb-caching-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	cache, err := OpenCacheAt(filepath.Join(tmpDir, "test.db"))
+	if err != nil {
+		t.Fatalf("failed to open cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	ctx := context.Background()
+	opts := CacheOptions{SearchTTL: 7 * 24 * time.Hour, NotFoundTTL: time.Hour}
+
+	found := &MockFetcher{SearchResponse: []byte(`{"words":[{"wordId":1,"wordValue":"puu","lang":"est"}]}`)}
+	notFound := &MockFetcher{SearchResponse: []byte(`{"words":[{"wordId":2,"wordValue":"tree","lang":"eng"}]}`)}
+
+	for _, f := range []struct {
+		word string
+		mock *MockFetcher
+	}{{"puu", found}, {"tree", notFound}} {
+		if _, err := NewCachingFetcher(f.mock, cache, opts).Search(ctx, f.word); err != nil {
+			t.Fatalf("Search(%q) error: %v", f.word, err)
+		}
+	}
+
+	t.Run("not-found result served from cache within its TTL", func(t *testing.T) {
+		fetcher := NewCachingFetcher(notFound, cache, opts)
+		fetcher.now = func() time.Time { return time.Now().Add(30 * time.Minute) }
+		if _, err := fetcher.Search(ctx, "tree"); err != nil {
+			t.Fatalf("Search() error: %v", err)
+		}
+		if len(notFound.SearchCalls) != 1 {
+			t.Errorf("expected cache hit, got %d upstream calls", len(notFound.SearchCalls))
+		}
+	})
+
+	t.Run("not-found result expires sooner than found results", func(t *testing.T) {
+		later := func() time.Time { return time.Now().Add(2 * time.Hour) }
+
+		fetcher := NewCachingFetcher(notFound, cache, opts)
+		fetcher.now = later
+		if _, err := fetcher.Search(ctx, "tree"); err != nil {
+			t.Fatalf("Search() error: %v", err)
+		}
+		if len(notFound.SearchCalls) != 2 {
+			t.Errorf("expected negative entry to expire, got %d upstream calls", len(notFound.SearchCalls))
+		}
+
+		fetcher = NewCachingFetcher(found, cache, opts)
+		fetcher.now = later
+		if _, err := fetcher.Search(ctx, "puu"); err != nil {
+			t.Fatalf("Search() error: %v", err)
+		}
+		if len(found.SearchCalls) != 1 {
+			t.Errorf("expected found entry still fresh, got %d upstream calls", len(found.SearchCalls))
+		}
+	})
+
+	t.Run("refresh bypasses negative cache", func(t *testing.T) {
+		calls := len(notFound.SearchCalls)
+		fetcher := NewCachingFetcher(notFound, cache, CacheOptions{Refresh: true, NotFoundTTL: time.Hour})
+		if _, err := fetcher.Search(ctx, "tree"); err != nil {
+			t.Fatalf("Search() error: %v", err)
+		}
+		if len(notFound.SearchCalls) != calls+1 {
+			t.Errorf("expected refresh to call upstream")
+		}
+	})
+}
+
+func TestIsNotFoundSearch(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want bool
+	}{
+		{"estonian word", `{"words":[{"wordId":1,"lang":"est"}]}`, false},
+		{"only other languages", `{"words":[{"wordId":1,"lang":"eng"}]}`, true},
+		{"empty", `{"words":[]}`, true},
+		{"invalid json", `oops`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isNotFoundSearch([]byte(tt.data)); got != tt.want {
+				t.Errorf("isNotFoundSearch(%s) = %v, want %v", tt.data, got, tt.want)
+			}
+		})
+	}
+}
@@ -2,20 +2,146 @@ package main
 
 import (
 	"bufio"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type Config struct {
-	APIKey     string
-	JSON       bool
-	All        bool
-	Quiet      bool
-	Version    bool
-	Homonym    int
-	Refresh    bool
-	ClearCache bool
+	APIKey       string
+	Raw          bool
+	Format       string
+	NoHeader     bool
+	Template     string
+	AnkiFront    string
+	AnkiBack     string
+	All          bool
+	Grid         bool
+	Quiet        bool
+	Version      bool
+	Homonym      int
+	Refresh      bool
+	ClearCache   bool
+	Timeout      time.Duration
+	Retries      int
+	RetryDelay   time.Duration
+	RateLimit    float64
+	Batch        string
+	Jobs         int
+	SearchTTL    time.Duration
+	DetailsTTL   time.Duration
+	ParadigmTTL  time.Duration
+	NotFoundTTL  time.Duration
+	MaxAge       time.Duration
+	Offline      bool
+	StaleOK      bool
+	CacheSize    ByteSize
+	CacheCount   int
+	CacheBackend string
+}
+
+// separateResults reports whether batch results are separated by blank
+// lines, for formats meant to be read by people.
+func (c Config) separateResults() bool {
+	if c.Raw || c.Template != "" {
+		return false
+	}
+	switch c.Format {
+	case formatText, "":
+		return !c.Quiet
+	case formatMarkdown:
+		return true
+	}
+	return false
+}
+
+// JSONOutput reports whether results, and errors, are written as JSON.
+func (c Config) JSONOutput() bool {
+	return c.Raw || c.Format == formatJSON
+}
+
+// CacheLimits builds the cache size limits from the configured flags.
+func (c Config) CacheLimits() CacheLimits {
+	return CacheLimits{
+		MaxEntries: c.CacheCount,
+		MaxBytes:   int64(c.CacheSize),
+	}
+}
+
+// ByteSize is a flag.Value accepting sizes such as "512KiB", "100MB" or
+// plain byte counts. Units are binary: K, M and G all mean powers of 1024.
+type ByteSize int64
+
+func (b *ByteSize) String() string {
+	return formatBytes(int64(*b))
+}
+
+func (b *ByteSize) Set(s string) error {
+	n, err := parseByteSize(s)
+	if err != nil {
+		return err
+	}
+	*b = ByteSize(n)
+	return nil
+}
+
+func parseByteSize(value string) (int64, error) {
+	s := strings.TrimSpace(value)
+	upper := strings.ToUpper(s)
+	multipliers := []struct {
+		suffix string
+		mult   int64
+	}{
+		{"GIB", 1 << 30}, {"MIB", 1 << 20}, {"KIB", 1 << 10},
+		{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10},
+		{"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10},
+		{"B", 1},
+	}
+	mult := int64(1)
+	for _, m := range multipliers {
+		if strings.HasSuffix(upper, m.suffix) {
+			mult = m.mult
+			s = strings.TrimSpace(s[:len(s)-len(m.suffix)])
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+	return int64(n * float64(mult)), nil
+}
+
+// RetryPolicy builds the API retry policy from the configured flags.
+func (c Config) RetryPolicy() RetryPolicy {
+	policy := DefaultRetryPolicy
+	policy.MaxAttempts = c.Retries + 1
+	policy.BaseDelay = c.RetryDelay
+	return policy
+}
+
+// CacheOptions builds the cache options from the configured flags.
+// A positive MaxAge overrides every per-endpoint TTL.
+func (c Config) CacheOptions() CacheOptions {
+	opts := CacheOptions{
+		Refresh:      c.Refresh,
+		Offline:      c.Offline,
+		StaleIfError: c.StaleOK,
+		SearchTTL:    c.SearchTTL,
+		DetailsTTL:   c.DetailsTTL,
+		ParadigmTTL:  c.ParadigmTTL,
+		NotFoundTTL:  c.NotFoundTTL,
+	}
+	if c.MaxAge > 0 {
+		opts.SearchTTL = c.MaxAge
+		opts.DetailsTTL = c.MaxAge
+		opts.ParadigmTTL = c.MaxAge
+		opts.NotFoundTTL = c.MaxAge
+	}
+	return opts
 }
 
 func loadConfigFile() string {
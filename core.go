@@ -26,12 +26,12 @@ func FilterEstonianWords(words []WordMatch) []WordMatch {
 
 func SelectHomonym(words []WordMatch, homonymIndex int) (WordMatch, error) {
 	if len(words) == 0 {
-		return WordMatch{}, fmt.Errorf("no words available")
+		return WordMatch{}, ErrWordNotFound
 	}
 
 	idx := homonymIndex - 1
 	if idx < 0 || idx >= len(words) {
-		return WordMatch{}, fmt.Errorf("homonym %d not found (have %d)", homonymIndex, len(words))
+		return WordMatch{}, &homonymError{Index: homonymIndex, Count: len(words)}
 	}
 
 	return words[idx], nil
@@ -121,28 +121,66 @@ func BuildFormMap(forms []Form) map[string]string {
 	return formMap
 }
 
+// MergeForms merges the forms of all paradigms. It returns every morph
+// code in order of first appearance, and the unique values for each code.
+func MergeForms(paradigms []Paradigm) (codes []string, forms map[string][]string) {
+	forms = make(map[string][]string)
+	seenValues := make(map[string]map[string]bool)
+
+	for _, paradigm := range paradigms {
+		for _, f := range paradigm.Forms {
+			code := strings.TrimSpace(f.MorphCode)
+			value := strings.TrimSpace(f.Value)
+
+			if seenValues[code] == nil {
+				seenValues[code] = make(map[string]bool)
+				codes = append(codes, code)
+			}
+			if !seenValues[code][value] {
+				seenValues[code][value] = true
+				forms[code] = append(forms[code], value)
+			}
+		}
+	}
+	return codes, forms
+}
+
 type FormattedOutput struct {
 	Header       string
 	Translations []string
 	Lines        []FormLine
+
+	// Structured fields behind Header, for machine-readable formats
+	Word            string
+	WordID          int64 // Ekilex word ID, if known
+	HomonymIndex    int
+	HomonymCount    int
+	PartOfSpeech    string
+	InflectionTypes []string
+	Forms           map[string][]string // Every form by morph code, not just Lines
 }
 
 type FormLine struct {
-	Code  string
-	Label string
-	Value string
+	Code   string
+	Label  string
+	Value  string   // Values joined for display, or "-" if there are none
+	Values []string // Unique forms in API order
 }
 
 func FormatOutput(word string, details *WordDetails, homonymIndex, totalHomonyms int, showAll bool) FormattedOutput {
-	output := FormattedOutput{}
+	posLabel, isVerb := DeterminePartOfSpeech(details)
+	output := FormattedOutput{
+		Word:         word,
+		HomonymIndex: homonymIndex,
+		HomonymCount: totalHomonyms,
+		PartOfSpeech: posLabel,
+	}
 
 	if len(details.Paradigms) == 0 {
 		output.Header = "No paradigm data available"
 		return output
 	}
 
-	posLabel, isVerb := DeterminePartOfSpeech(details)
-
 	// Collect unique inflection types
 	var types []string
 	seenTypes := make(map[string]bool)
@@ -153,6 +191,7 @@ func FormatOutput(word string, details *WordDetails, homonymIndex, totalHomonyms
 			types = append(types, t)
 		}
 	}
+	output.InflectionTypes = types
 	typeStr := strings.Join(types, ", ")
 
 	if totalHomonyms > 1 {
@@ -164,45 +203,17 @@ func FormatOutput(word string, details *WordDetails, homonymIndex, totalHomonyms
 
 	output.Translations = ExtractEnglishTranslations(details)
 
-	// Merge forms from all paradigms: map[morphCode] -> unique values (preserving order)
-	mergedForms := make(map[string][]string)
-	seenValues := make(map[string]map[string]bool)
-
-	for _, paradigm := range details.Paradigms {
-		for _, f := range paradigm.Forms {
-			code := strings.TrimSpace(f.MorphCode)
-			value := strings.TrimSpace(f.Value)
-
-			if seenValues[code] == nil {
-				seenValues[code] = make(map[string]bool)
-			}
-			if !seenValues[code][value] {
-				seenValues[code][value] = true
-				mergedForms[code] = append(mergedForms[code], value)
-			}
-		}
-	}
+	allCodes, mergedForms := MergeForms(details.Paradigms)
+	output.Forms = mergedForms
 
 	if showAll {
-		// Collect all unique codes in order of first appearance
-		var allCodes []string
-		seenCodes := make(map[string]bool)
-		for _, paradigm := range details.Paradigms {
-			for _, f := range paradigm.Forms {
-				code := strings.TrimSpace(f.MorphCode)
-				if !seenCodes[code] {
-					seenCodes[code] = true
-					allCodes = append(allCodes, code)
-				}
-			}
-		}
-
 		for _, code := range allCodes {
 			values := mergedForms[code]
 			output.Lines = append(output.Lines, FormLine{
-				Code:  code,
-				Label: GetMorphLabel(code),
-				Value: strings.Join(values, ", "),
+				Code:   code,
+				Label:  GetMorphLabel(code),
+				Value:  strings.Join(values, ", "),
+				Values: values,
 			})
 		}
 	} else {
@@ -216,9 +227,10 @@ func FormatOutput(word string, details *WordDetails, homonymIndex, totalHomonyms
 				value = strings.Join(values, ", ")
 			}
 			output.Lines = append(output.Lines, FormLine{
-				Code:  code,
-				Label: GetMorphLabel(code),
-				Value: value,
+				Code:   code,
+				Label:  GetMorphLabel(code),
+				Value:  value,
+				Values: values,
 			})
 		}
 	}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"strings"
 	"testing"
 )
@@ -65,8 +66,18 @@ func TestSelectHomonym(t *testing.T) {
 
 func TestSelectHomonym_EmptyList(t *testing.T) {
 	_, err := SelectHomonym([]WordMatch{}, 1)
-	if err == nil {
-		t.Error("expected error for empty list")
+	if !errors.Is(err, ErrWordNotFound) {
+		t.Errorf("error = %v, want ErrWordNotFound", err)
+	}
+}
+
+func TestSelectHomonym_OutOfRangeError(t *testing.T) {
+	_, err := SelectHomonym([]WordMatch{{WordID: 1}, {WordID: 2}}, 3)
+	if !errors.Is(err, ErrHomonymOutOfRange) {
+		t.Errorf("error = %v, want ErrHomonymOutOfRange", err)
+	}
+	if err.Error() != "homonym 3 not found (have 2)" {
+		t.Errorf("error = %q", err)
 	}
 }
 
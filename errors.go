@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Errors a lookup can fail with. Check them with errors.Is; the returned
+// errors wrap them with details such as the word.
+var (
+	ErrWordNotFound      = errors.New("word not found")
+	ErrHomonymOutOfRange = errors.New("homonym not found")
+	ErrUnauthorized      = errors.New("API key rejected")
+	ErrRateLimited       = errors.New("rate limited by the API")
+)
+
+// APIError is returned for non-200 responses from the API. It matches
+// ErrUnauthorized for 401 and 403 and ErrRateLimited for 429.
+type APIError struct {
+	Status     string
+	StatusCode int
+	RetryAfter time.Duration // From the Retry-After header, if any
+}
+
+func (e *APIError) Error() string {
+	return "API error: " + e.Status
+}
+
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	}
+	return false
+}
+
+// NetworkError is returned when the API could not be reached or the
+// response could not be read.
+type NetworkError struct {
+	Err error
+}
+
+func (e *NetworkError) Error() string {
+	return "network error: " + e.Err.Error()
+}
+
+func (e *NetworkError) Unwrap() error {
+	return e.Err
+}
+
+// homonymError reports a -homonym index beyond the homonyms found.
+type homonymError struct {
+	Index int
+	Count int
+}
+
+func (e *homonymError) Error() string {
+	return fmt.Sprintf("homonym %d not found (have %d)", e.Index, e.Count)
+}
+
+func (e *homonymError) Is(target error) bool {
+	return target == ErrHomonymOutOfRange
+}
+
+// Exit codes, as documented in the README.
+const (
+	exitOK          = 0
+	exitNotFound    = 1
+	exitUsage       = 2
+	exitFailure     = 3
+	exitAuth        = 4
+	exitRateLimited = 5
+	exitNetwork     = 6
+	exitInterrupted = 130
+)
+
+// exitCode maps a lookup error to the process exit status.
+func exitCode(err error) int {
+	var netErr *NetworkError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, context.Canceled):
+		return exitInterrupted
+	case isNotFound(err):
+		return exitNotFound
+	case errors.Is(err, ErrUnauthorized):
+		return exitAuth
+	case errors.Is(err, ErrRateLimited):
+		return exitRateLimited
+	case errors.As(err, &netErr), errors.Is(err, context.DeadlineExceeded):
+		return exitNetwork
+	}
+	return exitFailure
+}
+
+// isNotFound reports whether a lookup failed because the word or the
+// requested homonym does not exist.
+func isNotFound(err error) bool {
+	return errors.Is(err, ErrWordNotFound) || errors.Is(err, ErrHomonymOutOfRange)
+}
+
+// ErrorOutput is the machine-readable form of a failed lookup, written in
+// place of the result in -json mode.
+type ErrorOutput struct {
+	Code       string `json:"code"`                  // Stable identifier, e.g. "word_not_found"
+	Message    string `json:"message"`               // Human-readable description
+	Word       string `json:"word"`                  // The word that was looked up
+	Homonyms   *int   `json:"homonyms,omitempty"`    // Estonian homonyms found, when known
+	HTTPStatus int    `json:"http_status,omitempty"` // Status of a failed API response
+	ExitCode   int    `json:"exit_code"`             // Exit status for this error alone
+}
+
+// NewErrorOutput describes the error from looking up word.
+func NewErrorOutput(word string, err error) ErrorOutput {
+	out := ErrorOutput{
+		Code:     errorCode(err),
+		Message:  err.Error(),
+		Word:     word,
+		ExitCode: exitCode(err),
+	}
+	if out.Code == "interrupted" {
+		out.Message = "interrupted"
+	}
+
+	var homErr *homonymError
+	var apiErr *APIError
+	switch {
+	case errors.As(err, &homErr):
+		out.Homonyms = &homErr.Count
+	case errors.Is(err, ErrWordNotFound):
+		none := 0
+		out.Homonyms = &none
+	case errors.As(err, &apiErr):
+		out.HTTPStatus = apiErr.StatusCode
+	}
+	return out
+}
+
+// errorCode returns the stable identifier of err for ErrorOutput.
+func errorCode(err error) string {
+	var netErr *NetworkError
+	var apiErr *APIError
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "interrupted"
+	case errors.Is(err, ErrWordNotFound):
+		return "word_not_found"
+	case errors.Is(err, ErrHomonymOutOfRange):
+		return "homonym_out_of_range"
+	case errors.Is(err, ErrUnauthorized):
+		return "unauthorized"
+	case errors.Is(err, ErrRateLimited):
+		return "rate_limited"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.As(err, &netErr):
+		return "network_error"
+	case errors.Is(err, ErrNotCached):
+		return "not_cached"
+	case errors.As(err, &apiErr):
+		return "api_error"
+	}
+	return "error"
+}
+
+// writeJSONError writes the error from looking up word as
+// {"error": {...}}, indented like the lookup results.
+func writeJSONError(w io.Writer, word string, err error) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Error ErrorOutput `json:"error"`
+	}{NewErrorOutput(word, err)})
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"success", nil, exitOK},
+		{"word not found", fmt.Errorf("%w: xyzzy", ErrWordNotFound), exitNotFound},
+		{"homonym out of range", &homonymError{Index: 3, Count: 2}, exitNotFound},
+		{"unauthorized", &APIError{Status: "401 Unauthorized", StatusCode: http.StatusUnauthorized}, exitAuth},
+		{"forbidden", &APIError{Status: "403 Forbidden", StatusCode: http.StatusForbidden}, exitAuth},
+		{"rate limited", &APIError{Status: "429 Too Many Requests", StatusCode: http.StatusTooManyRequests}, exitRateLimited},
+		{"server error", &APIError{Status: "500 Internal Server Error", StatusCode: http.StatusInternalServerError}, exitFailure},
+		{"network", &NetworkError{Err: errors.New("connection refused")}, exitNetwork},
+		{"timeout", fmt.Errorf("timed out after 1s: %w", context.DeadlineExceeded), exitNetwork},
+		{"interrupted", context.Canceled, exitInterrupted},
+		{"offline miss", fmt.Errorf("%w: search:puu", ErrNotCached), exitFailure},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCode(tt.err); got != tt.want {
+				t.Errorf("exitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIError_WrappedStillMatches(t *testing.T) {
+	err := fmt.Errorf("search: %w", &APIError{Status: "401 Unauthorized", StatusCode: http.StatusUnauthorized})
+
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Error("wrapped 401 should match ErrUnauthorized")
+	}
+	if errors.Is(err, ErrRateLimited) {
+		t.Error("401 should not match ErrRateLimited")
+	}
+	if err.Error() != "search: API error: 401 Unauthorized" {
+		t.Errorf("Error() = %q", err)
+	}
+}
+
+func TestNewErrorOutput(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			"word not found",
+			fmt.Errorf("%w: xyzzy", ErrWordNotFound),
+			`{"code":"word_not_found","message":"word not found: xyzzy","word":"xyzzy","homonyms":0,"exit_code":1}`,
+		},
+		{
+			"homonym out of range",
+			&homonymError{Index: 3, Count: 2},
+			`{"code":"homonym_out_of_range","message":"homonym 3 not found (have 2)","word":"xyzzy","homonyms":2,"exit_code":1}`,
+		},
+		{
+			"unauthorized",
+			&APIError{Status: "401 Unauthorized", StatusCode: http.StatusUnauthorized},
+			`{"code":"unauthorized","message":"API error: 401 Unauthorized","word":"xyzzy","http_status":401,"exit_code":4}`,
+		},
+		{
+			"timeout",
+			fmt.Errorf("timed out after 1s: %w", context.DeadlineExceeded),
+			`{"code":"timeout","message":"timed out after 1s: context deadline exceeded","word":"xyzzy","exit_code":6}`,
+		},
+		{
+			"interrupted",
+			fmt.Errorf("network error: %w", context.Canceled),
+			`{"code":"interrupted","message":"interrupted","word":"xyzzy","exit_code":130}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(NewErrorOutput("xyzzy", tt.err))
+			if err != nil {
+				t.Fatalf("Marshal() error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteJSONError(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeJSONError(&buf, "xyzzy", fmt.Errorf("%w: xyzzy", ErrWordNotFound)); err != nil {
+		t.Fatalf("writeJSONError() error: %v", err)
+	}
+
+	want := `{
+  "error": {
+    "code": "word_not_found",
+    "message": "word not found: xyzzy",
+    "word": "xyzzy",
+    "homonyms": 0,
+    "exit_code": 1
+  }
+}
+`
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+}
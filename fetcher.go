@@ -1,24 +1,29 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"time"
 )
 
 // Fetcher abstracts API access for testability and caching.
 type Fetcher interface {
-	Search(word string) ([]byte, error)
-	WordDetails(wordID int64) ([]byte, error)
-	ParadigmDetails(wordID int64) ([]byte, error)
+	Search(ctx context.Context, word string) ([]byte, error)
+	WordDetails(ctx context.Context, wordID int64) ([]byte, error)
+	ParadigmDetails(ctx context.Context, wordID int64) ([]byte, error)
 }
 
-// APIFetcher fetches data directly from the Ekilex API.
+// APIFetcher fetches data directly from the Ekilex API, making a single
+// attempt per call; see RetryingFetcher for retries.
 type APIFetcher struct {
 	client  *http.Client
 	apiKey  string
 	baseURL string
+	timeout time.Duration // Per request; zero means none
 }
 
 func NewAPIFetcher(apiKey string) *APIFetcher {
@@ -29,20 +34,41 @@ func NewAPIFetcher(apiKey string) *APIFetcher {
 	}
 }
 
-func (f *APIFetcher) Search(word string) ([]byte, error) {
-	return f.get("/word/search/" + url.PathEscape(word))
+// SetTimeout bounds each request, from sending it to reading the whole
+// response. Time spent before the call, such as waiting for a rate limit
+// token or a retry backoff, does not count. Zero disables the timeout.
+func (f *APIFetcher) SetTimeout(d time.Duration) {
+	f.timeout = d
 }
 
-func (f *APIFetcher) WordDetails(wordID int64) ([]byte, error) {
-	return f.get(fmt.Sprintf("/word/details/%d", wordID))
+func (f *APIFetcher) Search(ctx context.Context, word string) ([]byte, error) {
+	return f.get(ctx, "/word/search/"+url.PathEscape(word))
 }
 
-func (f *APIFetcher) ParadigmDetails(wordID int64) ([]byte, error) {
-	return f.get(fmt.Sprintf("/paradigm/details/%d", wordID))
+func (f *APIFetcher) WordDetails(ctx context.Context, wordID int64) ([]byte, error) {
+	return f.get(ctx, fmt.Sprintf("/word/details/%d", wordID))
 }
 
-func (f *APIFetcher) get(path string) ([]byte, error) {
-	req, err := http.NewRequest("GET", f.baseURL+path, nil)
+func (f *APIFetcher) ParadigmDetails(ctx context.Context, wordID int64) ([]byte, error) {
+	return f.get(ctx, fmt.Sprintf("/paradigm/details/%d", wordID))
+}
+
+func (f *APIFetcher) get(ctx context.Context, path string) ([]byte, error) {
+	if f.timeout <= 0 {
+		return f.getOnce(ctx, path)
+	}
+	reqCtx, cancel := context.WithTimeout(ctx, f.timeout)
+	defer cancel()
+
+	data, err := f.getOnce(reqCtx, path)
+	if err != nil && ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded) {
+		return nil, fmt.Errorf("timed out after %s: %w", f.timeout, err)
+	}
+	return data, err
+}
+
+func (f *APIFetcher) getOnce(ctx context.Context, path string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", f.baseURL+path, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -50,13 +76,22 @@ func (f *APIFetcher) get(path string) ([]byte, error) {
 
 	resp, err := f.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("network error: %w", err)
+		return nil, &NetworkError{Err: err}
 	}
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error: %s", resp.Status)
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil, &APIError{
+			Status:     resp.Status,
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
 	}
 
-	return io.ReadAll(resp.Body)
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &NetworkError{Err: err}
+	}
+	return data, nil
 }
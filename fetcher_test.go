@@ -0,0 +1,388 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestAPIFetcher(baseURL string) *APIFetcher {
+	f := NewAPIFetcher("test-key")
+	f.baseURL = baseURL
+	return f
+}
+
+func TestAPIFetcher_SendsAPIKey(t *testing.T) {
+	var gotKey, gotPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotKey = r.Header.Get("ekilex-api-key")
+		gotPath = r.URL.Path
+		_, _ = w.Write([]byte(`{"words":[]}`))
+	}))
+	defer srv.Close()
+
+	data, err := newTestAPIFetcher(srv.URL).Search(context.Background(), "puu")
+	if err != nil {
+		t.Fatalf("Search() error: %v", err)
+	}
+	if string(data) != `{"words":[]}` {
+		t.Errorf("unexpected response: %s", data)
+	}
+	if gotKey != "test-key" {
+		t.Errorf("expected api key header 'test-key', got %q", gotKey)
+	}
+	if gotPath != "/word/search/puu" {
+		t.Errorf("expected path /word/search/puu, got %q", gotPath)
+	}
+}
+
+func TestAPIFetcher_ContextTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := newTestAPIFetcher(srv.URL).WordDetails(ctx, 1)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("request was not cancelled promptly (took %v)", elapsed)
+	}
+}
+
+func TestAPIFetcher_RequestTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	f := newTestAPIFetcher(srv.URL)
+	f.SetTimeout(50 * time.Millisecond)
+
+	_, err := f.Search(context.Background(), "puu")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if !strings.HasPrefix(err.Error(), "timed out after 50ms") {
+		t.Errorf("error = %q, want it to name the timeout", err)
+	}
+	if exitCode(err) != exitNetwork {
+		t.Errorf("exitCode() = %d, want %d", exitCode(err), exitNetwork)
+	}
+}
+
+func TestAPIFetcher_ContextCanceled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+
+	_, err := newTestAPIFetcher(srv.URL).ParadigmDetails(ctx, 1)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled, got %v", err)
+	}
+}
+
+func TestRetryingFetcher_RetriesTransientErrors(t *testing.T) {
+	statuses := []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK}
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := statuses[calls]
+		calls++
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+
+	f := NewRetryingFetcher(newTestAPIFetcher(srv.URL), RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond})
+
+	data, err := f.ParadigmDetails(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("ParadigmDetails() error: %v", err)
+	}
+	if string(data) != `[]` {
+		t.Errorf("unexpected response: %s", data)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 calls, got %d", calls)
+	}
+}
+
+func TestRetryingFetcher_GivesUpAfterMaxAttempts(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	f := NewRetryingFetcher(newTestAPIFetcher(srv.URL), RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond})
+
+	_, err := f.Search(context.Background(), "puu")
+	if err == nil {
+		t.Fatal("expected error after exhausting retries")
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 calls, got %d", calls)
+	}
+}
+
+func TestRetryingFetcher_DoesNotRetryPermanentErrors(t *testing.T) {
+	for _, status := range []int{http.StatusUnauthorized, http.StatusNotFound, http.StatusInternalServerError} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			var calls int
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				w.WriteHeader(status)
+			}))
+			defer srv.Close()
+
+			f := NewRetryingFetcher(newTestAPIFetcher(srv.URL), RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond})
+
+			if _, err := f.Search(context.Background(), "puu"); err == nil {
+				t.Fatal("expected error")
+			}
+			if calls != 1 {
+				t.Errorf("expected 1 call, got %d", calls)
+			}
+		})
+	}
+}
+
+func TestAPIFetcher_TypedErrors(t *testing.T) {
+	tests := []struct {
+		status int
+		want   error
+	}{
+		{http.StatusUnauthorized, ErrUnauthorized},
+		{http.StatusForbidden, ErrUnauthorized},
+		{http.StatusTooManyRequests, ErrRateLimited},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			_, err := newTestAPIFetcher(srv.URL).Search(context.Background(), "puu")
+			if !errors.Is(err, tt.want) {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.status {
+				t.Errorf("error = %#v, want *APIError with status %d", err, tt.status)
+			}
+		})
+	}
+}
+
+func TestAPIFetcher_NetworkError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.Close()
+
+	_, err := newTestAPIFetcher(srv.URL).Search(context.Background(), "puu")
+	var netErr *NetworkError
+	if !errors.As(err, &netErr) {
+		t.Fatalf("error = %v, want *NetworkError", err)
+	}
+	if exitCode(err) != exitNetwork {
+		t.Errorf("exitCode() = %d, want %d", exitCode(err), exitNetwork)
+	}
+}
+
+func TestRetryingFetcher_RetriesTruncatedBody(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls > 1 {
+			_, _ = w.Write([]byte(`{"words":[]}`))
+			return
+		}
+		// Promise more body than is sent, then drop the connection
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Errorf("Hijack() error: %v", err)
+			return
+		}
+		_, _ = buf.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 100\r\n\r\n{\"words\"")
+		_ = buf.Flush()
+		_ = conn.Close()
+	}))
+	defer srv.Close()
+
+	f := NewRetryingFetcher(newTestAPIFetcher(srv.URL), RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond})
+
+	data, err := f.Search(context.Background(), "puu")
+	if err != nil {
+		t.Fatalf("Search() error: %v", err)
+	}
+	if string(data) != `{"words":[]}` {
+		t.Errorf("unexpected response: %s", data)
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 calls, got %d", calls)
+	}
+}
+
+func TestRetryingFetcher_HonorsRetryAfter(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	var delays []time.Duration
+	f := NewRetryingFetcher(newTestAPIFetcher(srv.URL), RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Second})
+	f.sleep = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return nil
+	}
+
+	if _, err := f.WordDetails(context.Background(), 1); err != nil {
+		t.Fatalf("WordDetails() error: %v", err)
+	}
+	if len(delays) != 1 || delays[0] != 7*time.Second {
+		t.Errorf("expected a single 7s delay from Retry-After, got %v", delays)
+	}
+}
+
+func TestRetryingFetcher_GivesUpOnLongRetryAfter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	deadline, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		retry RetryPolicy
+	}{
+		{"past the deadline", deadline, RetryPolicy{MaxAttempts: 2}},
+		{"over MaxRetryAfter", context.Background(), RetryPolicy{MaxAttempts: 2, MaxRetryAfter: 30 * time.Second}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewRetryingFetcher(newTestAPIFetcher(srv.URL), tt.retry)
+			f.sleep = func(ctx context.Context, d time.Duration) error {
+				t.Errorf("slept %v, want no wait", d)
+				return nil
+			}
+
+			_, err := f.Search(tt.ctx, "puu")
+			if !errors.Is(err, ErrRateLimited) {
+				t.Fatalf("error = %v, want ErrRateLimited", err)
+			}
+			if exitCode(err) != exitRateLimited {
+				t.Errorf("exitCode() = %d, want %d", exitCode(err), exitRateLimited)
+			}
+		})
+	}
+}
+
+func TestRetryingFetcher_StopsOnCancel(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	f := NewRetryingFetcher(newTestAPIFetcher(srv.URL), RetryPolicy{MaxAttempts: 5, BaseDelay: time.Hour, MaxDelay: time.Hour})
+
+	_, err := f.Search(ctx, "puu")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancellation, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 call before cancellation, got %d", calls)
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	tests := []struct {
+		retry int
+		max   time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond},
+		{5, time.Second},
+		{10, time.Second},
+	}
+
+	for _, tt := range tests {
+		for i := 0; i < 20; i++ {
+			got := p.backoff(tt.retry)
+			if got < tt.max/2 || got > tt.max {
+				t.Errorf("backoff(%d) = %v, want in [%v, %v]", tt.retry, got, tt.max/2, tt.max)
+			}
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{"empty", "", 0},
+		{"seconds", "3", 3 * time.Second},
+		{"negative", "-1", 0},
+		{"http date", now.Add(5 * time.Second).Format(http.TimeFormat), 5 * time.Second},
+		{"past date", now.Add(-time.Minute).Format(http.TimeFormat), 0},
+		{"garbage", "soon", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRetryAfter(tt.value, now); got != tt.want {
+				t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
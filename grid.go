@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// paradigmGrid is a table of forms: one row per case or person, one
+// column per number or tense.
+type paradigmGrid struct {
+	corner  string // Label above the row labels
+	columns []string
+	rows    []gridRow
+}
+
+type gridRow struct {
+	label string
+	cells []string
+}
+
+// gridAxis is one row or column of a grid: the code part it matches and
+// its label.
+type gridAxis struct {
+	code  string
+	label string
+}
+
+// Noun cases in canonical order, nimetav to kaasaütlev. The short
+// illative follows the illative it is a variant of.
+var nounCases = []gridAxis{
+	{"N", "nimetav"},
+	{"G", "omastav"},
+	{"P", "osastav"},
+	{"Ill", "sisseütlev"},
+	{"Adt", "lühike sisseütlev"},
+	{"In", "seesütlev"},
+	{"El", "seestütlev"},
+	{"All", "alaleütlev"},
+	{"Ad", "alalütlev"},
+	{"Abl", "alaltütlev"},
+	{"Tr", "saav"},
+	{"Ter", "rajav"},
+	{"Es", "olev"},
+	{"Ab", "ilmaütlev"},
+	{"Kom", "kaasaütlev"},
+}
+
+var nounNumbers = []gridAxis{{"Sg", "ainsus"}, {"Pl", "mitmus"}}
+
+// verbMoods lists the finite verb forms: each mood with its tenses.
+var verbMoods = []struct {
+	gridAxis
+	tenses []gridAxis
+}{
+	{gridAxis{"Ind", "kindel kõneviis"}, []gridAxis{{"Pr", "olevik"}, {"Ipf", "minevik"}}},
+	{gridAxis{"Knd", "tingiv kõneviis"}, []gridAxis{{"Pr", "olevik"}, {"Pt", "minevik"}}},
+	{gridAxis{"Kvt", "käskiv kõneviis"}, []gridAxis{{"Pr", "olevik"}}},
+}
+
+var verbPersons = []gridAxis{
+	{"Sg1", "1.p ainsus"},
+	{"Sg2", "2.p ainsus"},
+	{"Sg3", "3.p ainsus"},
+	{"Pl1", "1.p mitmus"},
+	{"Pl2", "2.p mitmus"},
+	{"Pl3", "3.p mitmus"},
+	{"Ips", "umbisikuline"},
+	{"IpsNeg", "umbisikuline eitav"},
+}
+
+// buildGrid fills a grid whose cell codes are built by code(row, column),
+// marking the codes it shows in used. Rows without any form are dropped;
+// ok is false if no cell has a form.
+func buildGrid(corner string, rows, columns []gridAxis, forms map[string][]string, code func(row, column string) string, used map[string]bool) (grid paradigmGrid, ok bool) {
+	grid.corner = corner
+	for _, c := range columns {
+		grid.columns = append(grid.columns, c.label)
+	}
+	for _, r := range rows {
+		row := gridRow{label: r.label}
+		found := false
+		for _, c := range columns {
+			values := forms[code(r.code, c.code)]
+			if len(values) == 0 {
+				row.cells = append(row.cells, "-")
+				continue
+			}
+			found = true
+			used[code(r.code, c.code)] = true
+			row.cells = append(row.cells, strings.Join(values, ", "))
+		}
+		if found {
+			grid.rows = append(grid.rows, row)
+		}
+	}
+	return grid, len(grid.rows) > 0
+}
+
+// paradigmGrids arranges forms into grids: a case × number grid for
+// nouns and adjectives, or a person × tense grid per mood for verbs.
+// Codes that fit no grid are left out of used.
+func paradigmGrids(forms map[string][]string, isVerb bool) (grids []paradigmGrid, used map[string]bool) {
+	used = make(map[string]bool)
+	if !isVerb {
+		grid, ok := buildGrid("kääne", nounCases, nounNumbers, forms, func(caseCode, number string) string {
+			return number + caseCode
+		}, used)
+		if ok {
+			grids = append(grids, grid)
+		}
+		return grids, used
+	}
+
+	for _, mood := range verbMoods {
+		grid, ok := buildGrid(mood.label, verbPersons, mood.tenses, forms, func(person, tense string) string {
+			return mood.code + tense + person
+		}, used)
+		if ok {
+			grids = append(grids, grid)
+		}
+	}
+	return grids, used
+}
+
+// writeGrid writes a grid as aligned columns, indented like RenderOutput.
+func writeGrid(sb *strings.Builder, grid paradigmGrid) {
+	widths := make([]int, len(grid.columns)+1)
+	widths[0] = utf8.RuneCountInString(grid.corner)
+	for i, c := range grid.columns {
+		widths[i+1] = utf8.RuneCountInString(c)
+	}
+	for _, row := range grid.rows {
+		// Row labels are indented under the corner label
+		widths[0] = max(widths[0], utf8.RuneCountInString(row.label)+2)
+		for i, cell := range row.cells {
+			widths[i+1] = max(widths[i+1], utf8.RuneCountInString(cell))
+		}
+	}
+
+	writeRow := func(cells []string) {
+		sb.WriteString("  ")
+		for i, cell := range cells {
+			if i == len(cells)-1 {
+				sb.WriteString(cell)
+				break
+			}
+			sb.WriteString(cell)
+			sb.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)+3))
+		}
+		sb.WriteString("\n")
+	}
+
+	writeRow(append([]string{grid.corner}, grid.columns...))
+	for _, row := range grid.rows {
+		writeRow(append([]string{"  " + row.label}, row.cells...))
+	}
+}
+
+// RenderGrid renders all forms of a word as grids (see paradigmGrids),
+// followed by any forms that fit no grid, such as verb infinitives and
+// participles. ok is false if no form fits a grid, so callers can fall
+// back to RenderOutput.
+func RenderGrid(output FormattedOutput) (rendered string, ok bool) {
+	grids, used := paradigmGrids(output.Forms, output.PartOfSpeech == "verb")
+	if len(grids) == 0 {
+		return "", false
+	}
+
+	var sb strings.Builder
+	if output.Header != "" {
+		sb.WriteString(output.Header)
+		sb.WriteString("\n")
+	}
+	if len(output.Translations) > 0 {
+		sb.WriteString(fmt.Sprintf("  English: %s\n", strings.Join(output.Translations, ", ")))
+	}
+
+	for _, grid := range grids {
+		sb.WriteString("\n")
+		writeGrid(&sb, grid)
+	}
+
+	// Forms outside the grids, in the order of output.Lines
+	first := true
+	for _, line := range output.Lines {
+		if used[line.Code] {
+			continue
+		}
+		if first {
+			sb.WriteString("\n")
+			first = false
+		}
+		sb.WriteString(fmt.Sprintf("  %-45s %s\n", line.Label+":", line.Value))
+	}
+
+	return sb.String(), true
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// puuDetails is a noun with a short illative, a parallel form and a
+// plural stem that fits no grid.
+func puuDetails() *WordDetails {
+	forms := []Form{
+		{Value: "puu", MorphCode: "SgN"},
+		{Value: "puu", MorphCode: "SgG"},
+		{Value: "puud", MorphCode: "SgP"},
+		{Value: "puhu", MorphCode: "SgAdt"},
+		{Value: "puusse", MorphCode: "SgIll"},
+		{Value: "puus", MorphCode: "SgIn"},
+		{Value: "puust", MorphCode: "SgEl"},
+		{Value: "puule", MorphCode: "SgAll"},
+		{Value: "puul", MorphCode: "SgAd"},
+		{Value: "puult", MorphCode: "SgAbl"},
+		{Value: "puuks", MorphCode: "SgTr"},
+		{Value: "puuni", MorphCode: "SgTer"},
+		{Value: "puuna", MorphCode: "SgEs"},
+		{Value: "puuta", MorphCode: "SgAb"},
+		{Value: "puuga", MorphCode: "SgKom"},
+		{Value: "puud", MorphCode: "PlN"},
+		{Value: "puude", MorphCode: "PlG"},
+		{Value: "puid", MorphCode: "PlP"},
+		{Value: "puudesse", MorphCode: "PlIll"},
+		{Value: "puisse", MorphCode: "PlIll"},
+		{Value: "puudes", MorphCode: "PlIn"},
+		{Value: "puudeks", MorphCode: "PlTr"},
+		{Value: "puudega", MorphCode: "PlKom"},
+		{Value: "pui", MorphCode: "Rpl"},
+	}
+	return &WordDetails{
+		Paradigms: []Paradigm{{InflectionTypeNr: "26", Forms: forms}},
+		Lexemes: []Lexeme{{SynonymLangGroups: []SynonymLangGroup{{Lang: "eng", Synonyms: []Synonym{
+			{Words: []SynonymWord{{Lang: "eng", WordValue: "tree"}}},
+		}}}}},
+	}
+}
+
+func tegemaDetails() *WordDetails {
+	return &WordDetails{
+		WordClass: "verb",
+		Paradigms: []Paradigm{{InflectionTypeNr: "28", Forms: []Form{
+			{Value: "tegema", MorphCode: "Sup"},
+			{Value: "teha", MorphCode: "Inf"},
+			{Value: "teen", MorphCode: "IndPrSg1"},
+			{Value: "teeb", MorphCode: "IndPrSg3"},
+			{Value: "teevad", MorphCode: "IndPrPl3"},
+			{Value: "tehakse", MorphCode: "IndPrIps"},
+			{Value: "ei tehta", MorphCode: "IndPrIpsNeg"},
+			{Value: "tegin", MorphCode: "IndIpfSg1"},
+			{Value: "tegi", MorphCode: "IndIpfSg3"},
+			{Value: "tehti", MorphCode: "IndIpfIps"},
+			{Value: "teeksin", MorphCode: "KndPrSg1"},
+			{Value: "teinuksin", MorphCode: "KndPtSg1"},
+			{Value: "tee", MorphCode: "KvtPrSg2"},
+			{Value: "tehke", MorphCode: "KvtPrPl2"},
+			{Value: "tehes", MorphCode: "Ger"},
+			{Value: "tehtud", MorphCode: "PtsPtIps"},
+		}}},
+	}
+}
+
+// detailsFetcher serves details as the only homonym of word.
+func detailsFetcher(t *testing.T, word string, details *WordDetails) *MockFetcher {
+	t.Helper()
+	detailsJSON, err := json.Marshal(details)
+	if err != nil {
+		t.Fatal(err)
+	}
+	paradigmJSON, err := json.Marshal(details.Paradigms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &MockFetcher{
+		SearchResponse:   []byte(`{"words":[{"wordId":1,"wordValue":"` + word + `","lang":"est"}]}`),
+		DetailsResponse:  detailsJSON,
+		ParadigmResponse: paradigmJSON,
+	}
+}
+
+func TestRenderGrid_Golden(t *testing.T) {
+	tests := []struct {
+		name    string
+		word    string
+		details *WordDetails
+	}{
+		{"grid_noun.txt", "puu", puuDetails()},
+		{"grid_verb.txt", "tegema", tegemaDetails()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rendered, ok := RenderGrid(FormatOutput(tt.word, tt.details, 1, 1, true))
+			if !ok {
+				t.Fatal("RenderGrid() built no grid")
+			}
+			assertGolden(t, tt.name, []byte(rendered))
+		})
+	}
+}
+
+func TestParadigmGrids_NounOrder(t *testing.T) {
+	_, forms := MergeForms(puuDetails().Paradigms)
+	grids, used := paradigmGrids(forms, false)
+	if len(grids) != 1 {
+		t.Fatalf("got %d grids, want 1", len(grids))
+	}
+
+	var labels []string
+	for _, row := range grids[0].rows {
+		labels = append(labels, row.label)
+	}
+	// The short illative follows the illative, whatever the API order
+	if got := strings.Join(labels[:5], ","); got != "nimetav,omastav,osastav,sisseütlev,lühike sisseütlev" {
+		t.Errorf("row order = %s", got)
+	}
+	if got := grids[0].rows[3].cells; got[0] != "puusse" || got[1] != "puudesse, puisse" {
+		t.Errorf("sisseütlev row = %q", got)
+	}
+	if got := grids[0].rows[4].cells; got[1] != "-" {
+		t.Errorf("missing plural short illative = %q, want -", got[1])
+	}
+	if used["Rpl"] || !used["PlKom"] {
+		t.Errorf("used = %v", used)
+	}
+}
+
+func TestRenderGrid_NoGridFits(t *testing.T) {
+	output := FormatOutput("mine", &WordDetails{Paradigms: []Paradigm{{Forms: []Form{
+		{Value: "mine", MorphCode: "ID"},
+	}}}}, 1, 1, true)
+	if _, ok := RenderGrid(output); ok {
+		t.Error("RenderGrid() succeeded without grid forms")
+	}
+}
+
+func TestRender_GridOnlyForAllText(t *testing.T) {
+	list := RenderOutput(FormatOutput("puu", puuDetails(), 1, 1, true), false)
+	grid, _ := RenderGrid(FormatOutput("puu", puuDetails(), 1, 1, true))
+
+	tests := []struct {
+		name string
+		cfg  Config
+		want string
+	}{
+		{"all", Config{Homonym: 1, All: true, Grid: true}, grid},
+		{"grid off", Config{Homonym: 1, All: true}, list},
+		{"quiet", Config{Homonym: 1, All: true, Grid: true, Quiet: true},
+			RenderOutput(FormatOutput("puu", puuDetails(), 1, 1, true), true)},
+		{"key forms", Config{Homonym: 1, Grid: true},
+			RenderOutput(FormatOutput("puu", puuDetails(), 1, 1, false), false)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetcher := detailsFetcher(t, "puu", puuDetails())
+			var buf bytes.Buffer
+			if err := run(context.Background(), "puu", tt.cfg, fetcher, &buf); err != nil {
+				t.Fatalf("run() error: %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", buf.String(), tt.want)
+			}
+		})
+	}
+}
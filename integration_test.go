@@ -4,6 +4,7 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"os"
 	"path/filepath"
@@ -32,9 +33,9 @@ func TestIntegration_NounPuu(t *testing.T) {
 		Homonym: 1,
 	}
 
-	fetcher := NewAPIFetcher(apiKey)
+	fetcher := NewRetryingFetcher(NewAPIFetcher(apiKey), DefaultRetryPolicy)
 	var buf bytes.Buffer
-	err := run("puu", cfg, fetcher, &buf)
+	err := run(context.Background(), "puu", cfg, fetcher, &buf)
 	if err != nil {
 		t.Fatalf("run() error: %v", err)
 	}
@@ -63,9 +64,9 @@ func TestIntegration_VerbTegema(t *testing.T) {
 		Homonym: 1,
 	}
 
-	fetcher := NewAPIFetcher(apiKey)
+	fetcher := NewRetryingFetcher(NewAPIFetcher(apiKey), DefaultRetryPolicy)
 	var buf bytes.Buffer
-	err := run("tegema", cfg, fetcher, &buf)
+	err := run(context.Background(), "tegema", cfg, fetcher, &buf)
 	if err != nil {
 		t.Fatalf("run() error: %v", err)
 	}
@@ -95,9 +96,9 @@ func TestIntegration_AllForms(t *testing.T) {
 		All:     true,
 	}
 
-	fetcher := NewAPIFetcher(apiKey)
+	fetcher := NewRetryingFetcher(NewAPIFetcher(apiKey), DefaultRetryPolicy)
 	var buf bytes.Buffer
-	err := run("kass", cfg, fetcher, &buf)
+	err := run(context.Background(), "kass", cfg, fetcher, &buf)
 	if err != nil {
 		t.Fatalf("run() error: %v", err)
 	}
@@ -133,13 +134,13 @@ func TestIntegration_CachePopulated(t *testing.T) {
 	defer cache.Close()
 
 	// Make a real API call through caching fetcher
-	apiFetcher := NewAPIFetcher(apiKey)
-	fetcher := NewCachingFetcher(apiFetcher, cache, false)
+	retryingFetcher := NewRetryingFetcher(NewAPIFetcher(apiKey), DefaultRetryPolicy)
+	fetcher := NewCachingFetcher(retryingFetcher, cache, DefaultCacheOptions)
 
 	cfg := Config{APIKey: apiKey, Homonym: 1}
 	var buf bytes.Buffer
 	before := time.Now()
-	err = run("puu", cfg, fetcher, &buf)
+	err = run(context.Background(), "puu", cfg, fetcher, &buf)
 	if err != nil {
 		t.Fatalf("run() error: %v", err)
 	}
@@ -1,31 +1,68 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
+	"slices"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 )
 
 const (
-	version    = "0.1.4"
-	apiBaseURL = "https://ekilex.ee/api"
+	version        = "0.1.4"
+	apiBaseURL     = "https://ekilex.ee/api"
+	defaultTimeout = 30 * time.Second
+
+	defaultCacheSize = 100 << 20
 )
 
 func main() {
-	cfg := Config{Homonym: 1}
-	flag.BoolVar(&cfg.JSON, "json", false, "Output raw JSON")
+	cfg := Config{Homonym: 1, CacheSize: defaultCacheSize}
+	flag.StringVar(&cfg.Format, "format", formatText, "Output `format`: text, json, csv, tsv, markdown, yaml, anki-tsv or anki-csv")
+	flag.BoolVar(&cfg.NoHeader, "no-header", false, "Omit the column header in csv, tsv and anki output")
+	flag.StringVar(&cfg.AnkiFront, "anki-front", defaultAnkiFront, "Comma-separated `fields` on the front of Anki cards: word, translations, pos, type, forms or a morph code")
+	flag.StringVar(&cfg.AnkiBack, "anki-back", defaultAnkiBack, "Comma-separated `fields` on the back of Anki cards")
+	flag.StringVar(&cfg.Template, "template", "", "Render results with a Go text/`template`, e.g. '{{.Word}}: {{form \"SgG\"}}' (overrides -format)")
+	templateFile := flag.String("template-file", "", "Read the -template from `file`")
+	flag.BoolVar(&cfg.Raw, "raw", false, "Output the raw paradigm JSON from the API")
+	flag.BoolVar(&cfg.Raw, "json", false, "Same as -raw (deprecated)")
 	flag.BoolVar(&cfg.All, "all", false, "Show all forms")
+	flag.BoolVar(&cfg.Grid, "grid", true, "With -all, show noun cases and verb persons as tables (-grid=false lists one form per line)")
 	flag.BoolVar(&cfg.Quiet, "quiet", false, "Minimal output")
 	flag.BoolVar(&cfg.Quiet, "q", false, "Minimal output (shorthand)")
 	flag.BoolVar(&cfg.Version, "version", false, "Print version")
 	flag.IntVar(&cfg.Homonym, "homonym", 1, "Select homonym (when multiple exist)")
 	flag.BoolVar(&cfg.Refresh, "refresh", false, "Bypass cache and fetch fresh data")
 	flag.BoolVar(&cfg.ClearCache, "clear-cache", false, "Clear the cache and exit")
+	flag.DurationVar(&cfg.SearchTTL, "ttl-search", DefaultCacheOptions.SearchTTL, "How long cached search results stay fresh (0 = forever)")
+	flag.DurationVar(&cfg.DetailsTTL, "ttl-details", DefaultCacheOptions.DetailsTTL, "How long cached word details stay fresh (0 = forever)")
+	flag.DurationVar(&cfg.ParadigmTTL, "ttl-paradigm", DefaultCacheOptions.ParadigmTTL, "How long cached paradigms stay fresh (0 = forever)")
+	flag.DurationVar(&cfg.NotFoundTTL, "ttl-not-found", DefaultCacheOptions.NotFoundTTL, "How long cached \"word not found\" results stay fresh (0 = forever)")
+	flag.DurationVar(&cfg.MaxAge, "max-age", 0, "Treat cached entries older than this as expired, overriding the -ttl flags")
+	flag.BoolVar(&cfg.StaleOK, "stale-if-error", DefaultCacheOptions.StaleIfError, "Serve expired cache entries (with a warning) when the API fails")
+	flag.BoolVar(&cfg.Offline, "offline", false, "Use only the cache; never contact the API")
+	flag.Var(&cfg.CacheSize, "cache-max-size", "Maximum total `size` of cached data, e.g. 50MiB (0 = unlimited)")
+	flag.IntVar(&cfg.CacheCount, "cache-max-entries", 0, "Maximum number of cached entries (0 = unlimited)")
+	flag.StringVar(&cfg.CacheBackend, "cache-backend", backendSQLite, "Cache storage: sqlite, memory (this process only) or dir (one file per entry)")
+	flag.DurationVar(&cfg.Timeout, "timeout", defaultTimeout, "Maximum time for each API request (0 disables)")
+	flag.IntVar(&cfg.Retries, "retries", DefaultRetryPolicy.MaxAttempts-1, "Retries for transient API failures (429, 5xx, network)")
+	flag.DurationVar(&cfg.RetryDelay, "retry-delay", DefaultRetryPolicy.BaseDelay, "Initial backoff delay between retries")
+	flag.Float64Var(&cfg.RateLimit, "rate", 0, "Maximum API requests per second (0 = unlimited)")
+	flag.StringVar(&cfg.Batch, "batch", "", "Look up every word in `file`, one per line (- for stdin)")
+	flag.IntVar(&cfg.Jobs, "jobs", 1, "Number of concurrent lookups in batch and warm mode")
 	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: sonaveeb-cli <word> [flags]\n\n")
+		fmt.Fprintf(os.Stderr, "Usage: sonaveeb-cli <word> [flags]\n")
+		fmt.Fprintf(os.Stderr, "       sonaveeb-cli -batch <file> [flags]\n")
+		fmt.Fprintf(os.Stderr, "       sonaveeb-cli warm <file|-> [flags]\n")
+		fmt.Fprintf(os.Stderr, "       sonaveeb-cli cache <stats|list|inspect|delete|prune|clear|export|import> [args] [flags]\n\n")
 		fmt.Fprintf(os.Stderr, "Query Estonian word forms from Ekilex API\n\n")
 		fmt.Fprintf(os.Stderr, "Flags:\n")
 		flag.PrintDefaults()
@@ -34,22 +71,65 @@ func main() {
 		fmt.Fprintf(os.Stderr, "\nExamples:\n")
 		fmt.Fprintf(os.Stderr, "  sonaveeb-cli puu\n")
 		fmt.Fprintf(os.Stderr, "  sonaveeb-cli --all tegema\n")
-		fmt.Fprintf(os.Stderr, "  sonaveeb-cli --json puu\n")
+		fmt.Fprintf(os.Stderr, "  sonaveeb-cli --format json puu\n")
 		fmt.Fprintf(os.Stderr, "  sonaveeb-cli --refresh puu    # bypass cache\n")
+		fmt.Fprintf(os.Stderr, "  sonaveeb-cli --offline puu    # cache only\n")
+		fmt.Fprintf(os.Stderr, "  sonaveeb-cli --batch words.txt\n")
+		fmt.Fprintf(os.Stderr, "  sonaveeb-cli warm words.txt   # pre-fetch for offline use\n")
+		fmt.Fprintf(os.Stderr, "\nExit codes:\n")
+		fmt.Fprintf(os.Stderr, "  0 success, 1 word not found, 2 usage error, 3 other error, 4 API key rejected,\n")
+		fmt.Fprintf(os.Stderr, "  5 rate limited, 6 network error or timeout, 130 interrupted\n")
 	}
 	flag.Parse()
 
+	// Flags may also follow a subcommand, as in "warm words.txt -jobs 4"
+	subcommand := flag.Arg(0)
+	warm := subcommand == "warm"
+	var subArgs []string
+	if warm || subcommand == "cache" {
+		subArgs = parseInterspersed(flag.CommandLine, flag.Args()[1:])
+	}
+
 	if cfg.Version {
 		fmt.Println(version)
-		os.Exit(0)
+		os.Exit(exitOK)
+	}
+
+	if _, err := NewRenderer(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+
+	if *templateFile != "" {
+		if cfg.Template != "" {
+			fmt.Fprintln(os.Stderr, "error: -template and -template-file cannot be used together")
+			os.Exit(exitUsage)
+		}
+		data, err := os.ReadFile(*templateFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(exitUsage)
+		}
+		cfg.Template = string(data)
+	}
+	if cfg.Template != "" {
+		if _, err := parseTemplate(cfg.Template, nil); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(exitUsage)
+		}
+	}
+
+	if !slices.Contains(cacheBackends, cfg.CacheBackend) {
+		fmt.Fprintf(os.Stderr, "error: unknown -cache-backend %q (want %s)\n", cfg.CacheBackend, strings.Join(cacheBackends, ", "))
+		os.Exit(exitUsage)
 	}
 
 	// Handle --clear-cache before requiring a word
 	if cfg.ClearCache {
-		cache, err := OpenCache()
+		cache, err := OpenCacheStore(cfg.CacheBackend)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "error opening cache: %v\n", err)
-			os.Exit(3)
+			os.Exit(exitFailure)
 		}
 
 		clearErr := cache.Clear()
@@ -65,50 +145,197 @@ func main() {
 			hadError = true
 		}
 		if hadError {
-			os.Exit(3)
+			os.Exit(exitFailure)
 		}
 
 		fmt.Println("Cache cleared")
-		os.Exit(0)
+		os.Exit(exitOK)
 	}
 
-	if flag.NArg() < 1 {
+	if subcommand == "cache" {
+		if cfg.CacheBackend != backendSQLite {
+			fmt.Fprintf(os.Stderr, "error: the cache command needs the %s backend\n", backendSQLite)
+			os.Exit(exitUsage)
+		}
+		os.Exit(mainCache(cfg, subArgs))
+	}
+
+	if !warm && flag.NArg() < 1 && cfg.Batch == "" {
 		flag.Usage()
-		os.Exit(2)
+		os.Exit(exitUsage)
+	}
+
+	if cfg.Offline && cfg.Refresh {
+		fmt.Fprintln(os.Stderr, "error: -offline and -refresh cannot be used together")
+		os.Exit(exitUsage)
+	}
+
+	if warm {
+		if len(subArgs) != 1 {
+			fmt.Fprintln(os.Stderr, "error: usage: sonaveeb-cli warm <file|->")
+			os.Exit(exitUsage)
+		}
+		if cfg.Offline {
+			fmt.Fprintln(os.Stderr, "error: warm cannot be used with -offline")
+			os.Exit(exitUsage)
+		}
+		if cfg.CacheBackend == backendMemory {
+			fmt.Fprintln(os.Stderr, "error: warm needs a persistent -cache-backend")
+			os.Exit(exitUsage)
+		}
 	}
 
+	// The API key is only needed when we may contact the API
 	cfg.APIKey = os.Getenv("EKILEX_API_KEY")
 	if cfg.APIKey == "" {
 		cfg.APIKey = loadConfigFile()
 	}
-	if cfg.APIKey == "" {
+	if cfg.APIKey == "" && !cfg.Offline {
 		fmt.Fprintln(os.Stderr, "error: EKILEX_API_KEY not set (use env var or ~/.config/sonaveeb/config)")
-		os.Exit(2)
+		os.Exit(exitUsage)
 	}
 
-	// Open cache (nil is fine — caching is optional)
-	cache, err := OpenCache()
+	// Open cache (nil is fine — caching is optional, except offline)
+	cache, err := OpenCacheStore(cfg.CacheBackend)
 	if err != nil {
+		if cfg.Offline {
+			fmt.Fprintf(os.Stderr, "error: cache unavailable in offline mode: %v\n", err)
+			os.Exit(exitFailure)
+		}
 		fmt.Fprintf(os.Stderr, "warning: cache unavailable: %v\n", err)
 	}
 	if cache != nil {
+		if c, ok := cache.(*Cache); ok {
+			c.SetLimits(cfg.CacheLimits())
+		}
 		defer func() { _ = cache.Close() }()
 	}
 
-	word := flag.Arg(0)
+	// Cancel in-flight requests on Ctrl-C
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Retries sit outside the rate limiter, so each attempt takes a token
 	apiFetcher := NewAPIFetcher(cfg.APIKey)
-	fetcher := NewCachingFetcher(apiFetcher, cache, cfg.Refresh)
-	if err := run(word, cfg, fetcher, os.Stdout); err != nil {
+	apiFetcher.SetTimeout(cfg.Timeout)
+	limitedFetcher := NewRateLimitedFetcher(apiFetcher, cfg.RateLimit, 1)
+	retryingFetcher := NewRetryingFetcher(limitedFetcher, cfg.RetryPolicy())
+	fetcher := NewCachingFetcher(retryingFetcher, cache, cfg.CacheOptions())
+
+	if warm {
+		if cache == nil {
+			fmt.Fprintln(os.Stderr, "error: cannot warm cache: cache unavailable")
+			os.Exit(exitFailure)
+		}
+		os.Exit(mainWarm(ctx, subArgs[0], cfg, fetcher))
+	}
+
+	if cfg.Batch != "" {
+		os.Exit(mainBatch(ctx, cfg, fetcher))
+	}
+
+	word := flag.Arg(0)
+	if err := run(ctx, word, cfg, fetcher, os.Stdout); err != nil {
+		code := exitCode(err)
+		if cfg.JSONOutput() {
+			_ = writeJSONError(os.Stdout, word, err)
+			os.Exit(code)
+		}
+		if code == exitInterrupted {
+			err = errors.New("interrupted")
+		}
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(code)
+	}
+}
+
+// parseInterspersed parses flags anywhere in args, not only before the
+// first positional argument, and returns the positional arguments. Flags
+// after "--" are not parsed. fs must exit on error, as flag.CommandLine does.
+func parseInterspersed(fs *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		_ = fs.Parse(args)
+		rest := fs.Args()
+		if len(rest) == 0 {
+			return positional
+		}
+		// Parse consumed "--" itself if it stopped there
+		if len(args) > len(rest) && args[len(args)-len(rest)-1] == "--" {
+			return append(positional, rest...)
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+func mainCache(cfg Config, args []string) int {
+	cache, err := OpenCache()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error opening cache: %v\n", err)
+		return exitFailure
+	}
+	defer func() { _ = cache.Close() }()
+	// So that cache import stays within -cache-max-size and -cache-max-entries
+	cache.SetLimits(cfg.CacheLimits())
+
+	if err := runCacheCommand(cache, args, os.Stdout); err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
-		if strings.Contains(err.Error(), "not found") {
-			os.Exit(1)
+		if errors.Is(err, errCacheUsage) {
+			fmt.Fprintf(os.Stderr, "\n%s", cacheUsage)
+			return exitUsage
 		}
-		os.Exit(3)
+		return exitFailure
+	}
+	return exitOK
+}
+
+func mainBatch(ctx context.Context, cfg Config, fetcher Fetcher) int {
+	r, err := openWordList(cfg.Batch)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return exitUsage
+	}
+	words, err := ReadWords(r)
+	_ = r.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error reading word list: %v\n", err)
+		return exitFailure
+	}
+
+	summary := runBatch(ctx, words, cfg, fetcher, os.Stdout, os.Stderr)
+	fmt.Fprintf(os.Stderr, "\n%s", summary.Render())
+	if ctx.Err() != nil {
+		fmt.Fprintln(os.Stderr, "error: interrupted")
+		return exitInterrupted
+	}
+	return summary.ExitCode()
+}
+
+func mainWarm(ctx context.Context, path string, cfg Config, fetcher Fetcher) int {
+	r, err := openWordList(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return exitUsage
+	}
+	words, err := ReadWords(r)
+	_ = r.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error reading word list: %v\n", err)
+		return exitFailure
 	}
+
+	summary := runWarm(ctx, words, cfg, fetcher, os.Stderr)
+	fmt.Fprintf(os.Stderr, "\n%s", summary.Render())
+	if ctx.Err() != nil {
+		fmt.Fprintln(os.Stderr, "error: interrupted")
+		return exitInterrupted
+	}
+	return summary.ExitCode()
 }
 
-func run(word string, cfg Config, fetcher Fetcher, w io.Writer) error {
-	searchData, err := fetcher.Search(word)
+func run(ctx context.Context, word string, cfg Config, fetcher Fetcher, w io.Writer) error {
+	searchData, err := fetcher.Search(ctx, word)
 	if err != nil {
 		return err
 	}
@@ -120,7 +347,7 @@ func run(word string, cfg Config, fetcher Fetcher, w io.Writer) error {
 
 	estWords := FilterEstonianWords(searchResult.Words)
 	if len(estWords) == 0 {
-		return fmt.Errorf("word not found: %s", word)
+		return fmt.Errorf("%w: %s", ErrWordNotFound, word)
 	}
 
 	selectedWord, err := SelectHomonym(estWords, cfg.Homonym)
@@ -128,7 +355,7 @@ func run(word string, cfg Config, fetcher Fetcher, w io.Writer) error {
 		return err
 	}
 
-	detailsData, err := fetcher.WordDetails(selectedWord.WordID)
+	detailsData, paradigmsData, err := fetchWordData(ctx, fetcher, selectedWord.WordID)
 	if err != nil {
 		return err
 	}
@@ -138,12 +365,7 @@ func run(word string, cfg Config, fetcher Fetcher, w io.Writer) error {
 		return err
 	}
 
-	paradigmsData, err := fetcher.ParadigmDetails(selectedWord.WordID)
-	if err != nil {
-		return err
-	}
-
-	if cfg.JSON {
+	if cfg.Raw {
 		var prettyJSON interface{}
 		if err := json.Unmarshal(paradigmsData, &prettyJSON); err != nil {
 			return fmt.Errorf("failed to parse paradigms JSON: %w", err)
@@ -160,7 +382,57 @@ func run(word string, cfg Config, fetcher Fetcher, w io.Writer) error {
 	details.Paradigms = paradigms
 
 	output := FormatOutput(selectedWord.WordValue, details, cfg.Homonym, len(estWords), cfg.All)
-	rendered := RenderOutput(output, cfg.Quiet)
-	_, _ = fmt.Fprint(w, rendered)
-	return nil
+	output.WordID = selectedWord.WordID
+	if cfg.Template != "" {
+		return renderTemplate(w, cfg.Template, TemplateData{FormattedOutput: output, Details: details})
+	}
+	return render(w, cfg, output)
+}
+
+// fetchWordData fetches word details and paradigms concurrently, since
+// neither depends on the other. If either request fails, the other is
+// cancelled and the first error is returned.
+func fetchWordData(ctx context.Context, fetcher Fetcher, wordID int64) (details, paradigms []byte, err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		firstErr error
+	)
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		data, err := fetcher.WordDetails(ctx, wordID)
+		if err != nil {
+			fail(err)
+			return
+		}
+		details = data
+	}()
+	go func() {
+		defer wg.Done()
+		data, err := fetcher.ParadigmDetails(ctx, wordID)
+		if err != nil {
+			fail(err)
+			return
+		}
+		paradigms = data
+	}()
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, nil, firstErr
+	}
+	return details, paradigms, nil
 }
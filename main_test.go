@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// latencyServer is a fake Ekilex API that answers every request after a
+// fixed delay and records the peak number of concurrent requests.
+type latencyServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+}
+
+func newLatencyServer(t *testing.T, latency time.Duration) *latencyServer {
+	s := &latencyServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.inFlight++
+		if s.inFlight > s.peak {
+			s.peak = s.inFlight
+		}
+		s.mu.Unlock()
+		defer func() {
+			s.mu.Lock()
+			s.inFlight--
+			s.mu.Unlock()
+		}()
+
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/word/search/"):
+			_, _ = w.Write([]byte(`{"words":[{"wordId":1,"wordValue":"puu","lang":"est"}]}`))
+		case strings.HasPrefix(r.URL.Path, "/word/details/"):
+			_, _ = w.Write([]byte(`{"wordClass":"noun"}`))
+		case strings.HasPrefix(r.URL.Path, "/paradigm/details/"):
+			_, _ = w.Write([]byte(`[{"inflectionTypeNr":"26","paradigmForms":[{"value":"puu","morphCode":"SgN"}]}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestRun_FetchesDetailsAndParadigmsInParallel(t *testing.T) {
+	const latency = 100 * time.Millisecond
+	srv := newLatencyServer(t, latency)
+	fetcher := newTestAPIFetcher(srv.URL)
+
+	var buf bytes.Buffer
+	start := time.Now()
+	if err := run(context.Background(), "puu", Config{Homonym: 1}, fetcher, &buf); err != nil {
+		t.Fatalf("run() error: %v", err)
+	}
+	elapsed := time.Since(start)
+
+	// Search, then details and paradigms together: two round trips instead of three
+	t.Logf("lookup took %v with %v latency per request (sequential would be ~%v)", elapsed, latency, 3*latency)
+	if srv.peak != 2 {
+		t.Errorf("expected details and paradigms to be in flight together, peak was %d", srv.peak)
+	}
+	if elapsed >= 3*latency-latency/4 {
+		t.Errorf("expected ~%v for parallel fetch, took %v", 2*latency, elapsed)
+	}
+	if !strings.Contains(buf.String(), "puu (noun, type 26)") {
+		t.Errorf("unexpected output:\n%s", buf.String())
+	}
+}
+
+// failingFetcher fails ParadigmDetails immediately and blocks WordDetails
+// until its context is cancelled.
+type failingFetcher struct {
+	MockFetcher
+	detailsErr error
+}
+
+func (f *failingFetcher) WordDetails(ctx context.Context, wordID int64) ([]byte, error) {
+	<-ctx.Done()
+	f.detailsErr = ctx.Err()
+	return nil, ctx.Err()
+}
+
+func (f *failingFetcher) ParadigmDetails(ctx context.Context, wordID int64) ([]byte, error) {
+	return nil, &APIError{Status: "502 Bad Gateway", StatusCode: http.StatusBadGateway}
+}
+
+func TestFetchWordData_PropagatesFirstError(t *testing.T) {
+	fetcher := &failingFetcher{}
+
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := fetchWordData(context.Background(), fetcher, 1)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), "502") {
+			t.Errorf("expected paradigm error, got %v", err)
+		}
+		if !errors.Is(fetcher.detailsErr, context.Canceled) {
+			t.Errorf("expected details request to be cancelled, got %v", fetcher.detailsErr)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("fetchWordData did not cancel the outstanding request")
+	}
+}
+
+func TestFetchWordData_ReturnsBoth(t *testing.T) {
+	mock := &MockFetcher{
+		DetailsResponse:  []byte(`{"wordClass":"verb"}`),
+		ParadigmResponse: []byte(`[]`),
+	}
+
+	details, paradigms, err := fetchWordData(context.Background(), mock, 42)
+	if err != nil {
+		t.Fatalf("fetchWordData() error: %v", err)
+	}
+	if string(details) != `{"wordClass":"verb"}` || string(paradigms) != `[]` {
+		t.Errorf("unexpected data: %s / %s", details, paradigms)
+	}
+	if len(mock.DetailsCalls) != 1 || len(mock.ParadigmCalls) != 1 {
+		t.Errorf("expected one call each, got %d details and %d paradigm", len(mock.DetailsCalls), len(mock.ParadigmCalls))
+	}
+}
+
+func TestParseInterspersed(t *testing.T) {
+	tests := []struct {
+		args       []string
+		positional []string
+		jobs       int
+		rate       float64
+	}{
+		{[]string{"words.txt", "-jobs", "4", "-rate", "5"}, []string{"words.txt"}, 4, 5},
+		{[]string{"-jobs", "4", "-", "-rate=5"}, []string{"-"}, 4, 5},
+		{[]string{"a", "b"}, []string{"a", "b"}, 1, 0},
+		{[]string{"-jobs", "2", "--", "-rate", "5"}, []string{"-rate", "5"}, 2, 0},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ExitOnError)
+			jobs := fs.Int("jobs", 1, "")
+			rate := fs.Float64("rate", 0, "")
+
+			got := parseInterspersed(fs, tt.args)
+			if strings.Join(got, "|") != strings.Join(tt.positional, "|") {
+				t.Errorf("positional = %q, want %q", got, tt.positional)
+			}
+			if *jobs != tt.jobs || *rate != tt.rate {
+				t.Errorf("jobs = %d, rate = %g; want %d, %g", *jobs, *rate, tt.jobs, tt.rate)
+			}
+		})
+	}
+}
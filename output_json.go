@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// JSONOutput is the stable schema written by -format json. Fields are
+// never omitted; lists are empty rather than null.
+type JSONOutput struct {
+	Word            string     `json:"word"`
+	Homonym         int        `json:"homonym"`  // 1-based index of this homonym
+	Homonyms        int        `json:"homonyms"` // Estonian homonyms found
+	PartOfSpeech    string     `json:"part_of_speech"`
+	InflectionTypes []string   `json:"inflection_types"`
+	Translations    []string   `json:"translations"` // English
+	Lines           []JSONLine `json:"lines"`
+}
+
+// JSONLine is one morphological form of a JSONOutput.
+type JSONLine struct {
+	Code   string   `json:"code"`
+	Label  string   `json:"label"`
+	Values []string `json:"values"` // Empty if the word has no such form
+}
+
+// NewJSONOutput converts a formatted lookup result to the JSON schema.
+func NewJSONOutput(output FormattedOutput) JSONOutput {
+	out := JSONOutput{
+		Word:            output.Word,
+		Homonym:         output.HomonymIndex,
+		Homonyms:        output.HomonymCount,
+		PartOfSpeech:    output.PartOfSpeech,
+		InflectionTypes: nonNil(output.InflectionTypes),
+		Translations:    nonNil(output.Translations),
+		Lines:           make([]JSONLine, 0, len(output.Lines)),
+	}
+	for _, line := range output.Lines {
+		out.Lines = append(out.Lines, JSONLine{
+			Code:   line.Code,
+			Label:  line.Label,
+			Values: nonNil(line.Values),
+		})
+	}
+	return out
+}
+
+// writeJSONOutput writes output as indented JSON.
+func writeJSONOutput(w io.Writer, output FormattedOutput) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(NewJSONOutput(output))
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
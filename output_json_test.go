@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// pankFetcher serves a word with two Estonian homonyms, English
+// translations and a noun paradigm with one variant form.
+func pankFetcher() *MockFetcher {
+	return &MockFetcher{
+		SearchResponse: []byte(`{"words":[
+			{"wordId":1,"wordValue":"pank","lang":"est"},
+			{"wordId":2,"wordValue":"pank","lang":"est"}
+		]}`),
+		DetailsResponse: []byte(`{"wordClass":"","lexemes":[{"pos":[{"code":"s"}],"synonymLangGroups":[
+			{"lang":"eng","synonyms":[{"words":[{"wordValue":"bank","lang":"eng"},{"wordValue":"bench","lang":"eng"}]}]}
+		]}]}`),
+		ParadigmResponse: []byte(`[{"inflectionTypeNr":"22","paradigmForms":[
+			{"value":"pank","morphCode":"SgN"},
+			{"value":"panga","morphCode":"SgG"},
+			{"value":"panka","morphCode":"SgP"},
+			{"value":"pankasid","morphCode":"PlP"},
+			{"value":"panku","morphCode":"PlP"}
+		]}]`),
+	}
+}
+
+func TestNewJSONOutput_EmptyListsAreNotNull(t *testing.T) {
+	output := FormatOutput("puu", &WordDetails{}, 1, 1, false)
+
+	data, err := json.Marshal(NewJSONOutput(output))
+	if err != nil {
+		t.Fatalf("Marshal() error: %v", err)
+	}
+	want := `{"word":"puu","homonym":1,"homonyms":1,"part_of_speech":"noun","inflection_types":[],"translations":[],"lines":[]}`
+	if string(data) != want {
+		t.Errorf("got  %s\nwant %s", data, want)
+	}
+}
+
+func TestNewJSONOutput_MissingFormHasNoValues(t *testing.T) {
+	details := &WordDetails{Paradigms: []Paradigm{{InflectionTypeNr: "1", Forms: []Form{{Value: "puu", MorphCode: "SgN"}}}}}
+	out := NewJSONOutput(FormatOutput("puu", details, 1, 1, false))
+
+	for _, line := range out.Lines {
+		if line.Code == "SgG" && len(line.Values) != 0 {
+			t.Errorf("SgG values = %v, want none", line.Values)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimitedFetcher wraps a Fetcher with a token-bucket rate limiter.
+// It is safe for concurrent use; all callers share the same budget.
+type RateLimitedFetcher struct {
+	upstream Fetcher
+	limiter  *tokenBucket
+}
+
+// NewRateLimitedFetcher creates a fetcher allowing at most rps requests per
+// second on average, with bursts of up to burst requests.
+// If rps is zero or negative, requests are not limited.
+func NewRateLimitedFetcher(upstream Fetcher, rps float64, burst int) *RateLimitedFetcher {
+	var limiter *tokenBucket
+	if rps > 0 {
+		limiter = newTokenBucket(rps, burst)
+	}
+	return &RateLimitedFetcher{
+		upstream: upstream,
+		limiter:  limiter,
+	}
+}
+
+func (f *RateLimitedFetcher) Search(ctx context.Context, word string) ([]byte, error) {
+	if err := f.wait(ctx); err != nil {
+		return nil, err
+	}
+	return f.upstream.Search(ctx, word)
+}
+
+func (f *RateLimitedFetcher) WordDetails(ctx context.Context, wordID int64) ([]byte, error) {
+	if err := f.wait(ctx); err != nil {
+		return nil, err
+	}
+	return f.upstream.WordDetails(ctx, wordID)
+}
+
+func (f *RateLimitedFetcher) ParadigmDetails(ctx context.Context, wordID int64) ([]byte, error) {
+	if err := f.wait(ctx); err != nil {
+		return nil, err
+	}
+	return f.upstream.ParadigmDetails(ctx, wordID)
+}
+
+func (f *RateLimitedFetcher) wait(ctx context.Context) error {
+	if f.limiter == nil {
+		return ctx.Err()
+	}
+	return f.limiter.Wait(ctx)
+}
+
+// tokenBucket is a minimal token-bucket limiter. Tokens accrue at rate per
+// second up to burst; each Wait consumes one, sleeping if none is available.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+		now:    time.Now,
+	}
+}
+
+// reserve takes a token and returns how long the caller must wait before
+// using it. The balance may go negative, queueing callers in arrival order.
+func (b *tokenBucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+elapsed.Seconds()*b.rate)
+		b.last = now
+	}
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// cancel returns an unused token after an abandoned Wait.
+func (b *tokenBucket) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = math.Min(b.burst, b.tokens+1)
+}
+
+// Wait blocks until a token is available or ctx is done.
+func (b *tokenBucket) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	delay := b.reserve()
+	if err := sleepContext(ctx, delay); err != nil {
+		b.cancel()
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket_Reserve(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := newTokenBucket(2, 2)
+	b.last = now
+	b.now = func() time.Time { return now }
+
+	// Burst is available immediately
+	if d := b.reserve(); d != 0 {
+		t.Errorf("first reserve: expected no delay, got %v", d)
+	}
+	if d := b.reserve(); d != 0 {
+		t.Errorf("second reserve: expected no delay, got %v", d)
+	}
+
+	// Then callers queue at 1/rate intervals
+	if d := b.reserve(); d != 500*time.Millisecond {
+		t.Errorf("third reserve: expected 500ms, got %v", d)
+	}
+	if d := b.reserve(); d != time.Second {
+		t.Errorf("fourth reserve: expected 1s, got %v", d)
+	}
+
+	// Tokens refill over time, capped at burst
+	now = now.Add(time.Hour)
+	if d := b.reserve(); d != 0 {
+		t.Errorf("after refill: expected no delay, got %v", d)
+	}
+	if b.tokens != 1 {
+		t.Errorf("expected tokens capped at burst-1, got %v", b.tokens)
+	}
+}
+
+func TestRateLimitedFetcher_ThrottlesConcurrentCalls(t *testing.T) {
+	mock := &MockFetcher{SearchResponse: []byte(`{"words":[]}`)}
+	fetcher := NewRateLimitedFetcher(mock, 50, 1)
+
+	const calls = 6
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < calls; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := fetcher.Search(context.Background(), "puu"); err != nil {
+				t.Errorf("Search() error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// One call goes through immediately, the remaining five wait 20ms each
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("expected calls to be throttled to ~100ms, took %v", elapsed)
+	}
+	if len(mock.SearchCalls) != calls {
+		t.Errorf("expected %d upstream calls, got %d", calls, len(mock.SearchCalls))
+	}
+}
+
+func TestRateLimitedFetcher_Unlimited(t *testing.T) {
+	mock := &MockFetcher{DetailsResponse: []byte(`{}`)}
+	fetcher := NewRateLimitedFetcher(mock, 0, 0)
+
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		if _, err := fetcher.WordDetails(context.Background(), 1); err != nil {
+			t.Fatalf("WordDetails() error: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("unlimited fetcher should not throttle, took %v", elapsed)
+	}
+}
+
+func TestRateLimitedFetcher_WaitRespectsContext(t *testing.T) {
+	mock := &MockFetcher{ParadigmResponse: []byte(`[]`)}
+	fetcher := NewRateLimitedFetcher(mock, 0.1, 1)
+
+	// Use up the single token
+	if _, err := fetcher.ParadigmDetails(context.Background(), 1); err != nil {
+		t.Fatalf("ParadigmDetails() error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := fetcher.ParadigmDetails(ctx, 1)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if len(mock.ParadigmCalls) != 1 {
+		t.Errorf("expected cancelled call not to reach upstream, got %d calls", len(mock.ParadigmCalls))
+	}
+}
+
+func TestRetryingFetcher_EachAttemptTakesAToken(t *testing.T) {
+	var mu sync.Mutex
+	var times []time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		times = append(times, time.Now())
+		attempt := len(times)
+		mu.Unlock()
+		if attempt <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"words":[]}`))
+	}))
+	defer srv.Close()
+
+	// Wired as in main; with no backoff, only the limiter spaces the attempts
+	limited := NewRateLimitedFetcher(newTestAPIFetcher(srv.URL), 20, 1)
+	fetcher := NewRetryingFetcher(limited, RetryPolicy{MaxAttempts: 3})
+
+	if _, err := fetcher.Search(context.Background(), "puu"); err != nil {
+		t.Fatalf("Search() error: %v", err)
+	}
+	if len(times) != 3 {
+		t.Fatalf("expected 3 requests, got %d", len(times))
+	}
+	for i := 1; i < len(times); i++ {
+		if gap := times[i].Sub(times[i-1]); gap < 40*time.Millisecond {
+			t.Errorf("retry %d sent %v after the previous attempt, want ~50ms", i, gap)
+		}
+	}
+}
+
+func TestRateLimitedFetcher_QueueTimeIsNotRequestTime(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+
+	api := newTestAPIFetcher(srv.URL)
+	api.SetTimeout(100 * time.Millisecond)
+	fetcher := NewRateLimitedFetcher(api, 10, 1)
+
+	// The fourth call waits ~300ms for its token, longer than the timeout
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := fetcher.ParadigmDetails(context.Background(), 1); err != nil {
+				t.Errorf("ParadigmDetails() error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
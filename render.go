@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Renderer writes a formatted lookup result in one output format.
+type Renderer interface {
+	Render(w io.Writer, output FormattedOutput) error
+}
+
+// headerRenderer is implemented by tabular formats whose column header is
+// written once, before the first result.
+type headerRenderer interface {
+	RenderHeader(w io.Writer) error
+}
+
+// Output formats selectable with -format.
+const (
+	formatText     = "text"
+	formatJSON     = "json"
+	formatCSV      = "csv"
+	formatTSV      = "tsv"
+	formatMarkdown = "markdown"
+	formatYAML     = "yaml"
+	formatAnkiTSV  = "anki-tsv"
+	formatAnkiCSV  = "anki-csv"
+)
+
+var outputFormats = []string{formatText, formatJSON, formatCSV, formatTSV, formatMarkdown, formatYAML, formatAnkiTSV, formatAnkiCSV}
+
+// NewRenderer returns the renderer for cfg.Format.
+func NewRenderer(cfg Config) (Renderer, error) {
+	switch cfg.Format {
+	case formatText, "":
+		return textRenderer{quiet: cfg.Quiet, grid: cfg.Grid && cfg.All && !cfg.Quiet}, nil
+	case formatJSON:
+		return jsonRenderer{}, nil
+	case formatCSV:
+		return tableRenderer{comma: ',', crlf: true}, nil
+	case formatTSV:
+		return tableRenderer{comma: '\t'}, nil
+	case formatMarkdown:
+		return markdownRenderer{}, nil
+	case formatYAML:
+		return yamlRenderer{}, nil
+	case formatAnkiTSV:
+		return newAnkiRenderer('\t', cfg.AnkiFront, cfg.AnkiBack)
+	case formatAnkiCSV:
+		return newAnkiRenderer(',', cfg.AnkiFront, cfg.AnkiBack)
+	}
+	return nil, fmt.Errorf("unknown format %q (want %s)", cfg.Format, strings.Join(outputFormats, ", "))
+}
+
+// render writes output with the configured renderer, preceded by the
+// column header unless cfg.NoHeader is set.
+func render(w io.Writer, cfg Config, output FormattedOutput) error {
+	renderer, err := NewRenderer(cfg)
+	if err != nil {
+		return err
+	}
+	if h, ok := renderer.(headerRenderer); ok && !cfg.NoHeader {
+		if err := h.RenderHeader(w); err != nil {
+			return err
+		}
+	}
+	return renderer.Render(w, output)
+}
+
+// describe summarises a result as e.g. "pank (noun, type 22)".
+func describe(output FormattedOutput) string {
+	if len(output.InflectionTypes) == 0 {
+		return fmt.Sprintf("%s (%s)", output.Word, output.PartOfSpeech)
+	}
+	return fmt.Sprintf("%s (%s, type %s)", output.Word, output.PartOfSpeech, strings.Join(output.InflectionTypes, ", "))
+}
+
+type textRenderer struct {
+	quiet bool
+	grid  bool // Lay out all forms as grids where possible
+}
+
+func (r textRenderer) Render(w io.Writer, output FormattedOutput) error {
+	if r.grid {
+		if rendered, ok := RenderGrid(output); ok {
+			_, err := io.WriteString(w, rendered)
+			return err
+		}
+	}
+	_, err := io.WriteString(w, RenderOutput(output, r.quiet))
+	return err
+}
+
+type jsonRenderer struct{}
+
+func (jsonRenderer) Render(w io.Writer, output FormattedOutput) error {
+	return writeJSONOutput(w, output)
+}
+
+// tableRenderer writes one row per form, for spreadsheets: CSV as in
+// RFC 4180 (CRLF line endings, fields quoted as needed) or unquoted TSV.
+// Multiple values of a form share one field, separated by ", ".
+type tableRenderer struct {
+	comma rune
+	crlf  bool
+}
+
+var tableColumns = []string{"word", "homonym", "part_of_speech", "code", "label", "forms"}
+
+// tsvReplacer blanks out characters TSV fields cannot hold.
+var tsvReplacer = strings.NewReplacer("\t", " ", "\n", " ", "\r", " ")
+
+func (r tableRenderer) write(w io.Writer, records [][]string) error {
+	if r.comma != '\t' {
+		cw := csv.NewWriter(w)
+		cw.Comma = r.comma
+		cw.UseCRLF = r.crlf
+		return cw.WriteAll(records)
+	}
+
+	// TSV has no quoting: fields are written as they are, so they must not
+	// contain separators
+	var sb strings.Builder
+	for _, record := range records {
+		for i, field := range record {
+			if i > 0 {
+				sb.WriteByte('\t')
+			}
+			sb.WriteString(tsvReplacer.Replace(field))
+		}
+		sb.WriteByte('\n')
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func (r tableRenderer) RenderHeader(w io.Writer) error {
+	return r.write(w, [][]string{tableColumns})
+}
+
+func (r tableRenderer) Render(w io.Writer, output FormattedOutput) error {
+	var records [][]string
+	for _, line := range output.Lines {
+		records = append(records, []string{
+			output.Word,
+			fmt.Sprint(output.HomonymIndex),
+			output.PartOfSpeech,
+			line.Code,
+			line.Label,
+			strings.Join(line.Values, ", "),
+		})
+	}
+	return r.write(w, records)
+}
+
+// markdownRenderer writes a heading, the translations and a table of
+// forms, ready to paste into a wiki page.
+type markdownRenderer struct{}
+
+func (markdownRenderer) Render(w io.Writer, output FormattedOutput) error {
+	var sb strings.Builder
+	sb.WriteString("### " + markdownEscape(describe(output)))
+	if output.HomonymCount > 1 {
+		fmt.Fprintf(&sb, " — homonym %d of %d", output.HomonymIndex, output.HomonymCount)
+	}
+	sb.WriteString("\n\n")
+
+	if len(output.Translations) > 0 {
+		sb.WriteString("English: " + markdownEscape(strings.Join(output.Translations, ", ")) + "\n\n")
+	}
+
+	if len(output.Lines) == 0 {
+		sb.WriteString("No paradigm data available.\n")
+	} else {
+		sb.WriteString("| Form | Code | Value |\n")
+		sb.WriteString("|------|------|-------|\n")
+		for _, line := range output.Lines {
+			fmt.Fprintf(&sb, "| %s | %s | %s |\n",
+				markdownEscape(line.Label), markdownEscape(line.Code), markdownEscape(line.Value))
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// markdownEscape keeps text from breaking out of a table cell or being
+// read as emphasis.
+var markdownEscape = strings.NewReplacer(
+	`\`, `\\`, "|", `\|`, "*", `\*`, "_", `\_`, "`", "\\`", "\n", " ",
+).Replace
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata/golden")
+
+var formatExtensions = map[string]string{
+	formatText:     "txt",
+	formatJSON:     "json",
+	formatCSV:      "csv",
+	formatTSV:      "tsv",
+	formatMarkdown: "md",
+	formatYAML:     "yaml",
+	formatAnkiTSV:  "anki.tsv",
+	formatAnkiCSV:  "anki.csv",
+}
+
+// assertGolden compares got with testdata/golden/name, or rewrites the
+// file when the tests are run with -update.
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", "golden", name)
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading golden file (run with -update to create it): %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s mismatch\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+// trickyOutput has values that need quoting or escaping in every format.
+var trickyOutput = FormattedOutput{
+	Word:            "no",
+	WordID:          42,
+	HomonymIndex:    1,
+	HomonymCount:    1,
+	PartOfSpeech:    "noun",
+	InflectionTypes: []string{"22"},
+	Translations:    []string{`say "hi", please`, "key: value"},
+	Lines: []FormLine{
+		{Code: "SgN", Label: "ainsuse nimetav", Value: `a|b, "c", *d*`, Values: []string{"a|b", `"c"`, "*d*"}},
+		{Code: "SgG", Label: "ainsuse omastav", Value: "-"},
+	},
+	Forms: map[string][]string{"SgN": {"a|b", `"c"`, "*d*"}},
+}
+
+func TestRenderers_Golden(t *testing.T) {
+	for _, format := range outputFormats {
+		ext := formatExtensions[format]
+		t.Run(format, func(t *testing.T) {
+			cfg := Config{Homonym: 2, Format: format}
+			var buf bytes.Buffer
+			if err := run(context.Background(), "pank", cfg, pankFetcher(), &buf); err != nil {
+				t.Fatalf("run() error: %v", err)
+			}
+			assertGolden(t, "pank."+ext, buf.Bytes())
+
+			buf.Reset()
+			if err := render(&buf, cfg, trickyOutput); err != nil {
+				t.Fatalf("render() error: %v", err)
+			}
+			assertGolden(t, "tricky."+ext, buf.Bytes())
+		})
+	}
+}
+
+func TestRender_NoHeader(t *testing.T) {
+	var buf bytes.Buffer
+	if err := render(&buf, Config{Format: formatTSV, NoHeader: true}, trickyOutput); err != nil {
+		t.Fatalf("render() error: %v", err)
+	}
+	if strings.HasPrefix(buf.String(), "word\t") {
+		t.Errorf("header written despite NoHeader:\n%s", buf.String())
+	}
+}
+
+func TestRunBatch_CSVHeaderOnce(t *testing.T) {
+	fetcher := NewDictFetcher("puu", "maja")
+	cfg := Config{Homonym: 1, Format: formatCSV, Jobs: 2}
+	var out, errOut bytes.Buffer
+	runBatch(context.Background(), []string{"puu", "maja"}, cfg, fetcher, &out, &errOut)
+
+	want := "word,homonym,part_of_speech,code,label,forms\r\n" +
+		"puu,1,noun,SgN,ainsuse nimetav,puu\r\n" +
+		"puu,1,noun,SgG,ainsuse omastav,\r\n" +
+		"puu,1,noun,SgP,ainsuse osastav,\r\n" +
+		"puu,1,noun,PlP,mitmuse osastav,\r\n" +
+		"maja,1,noun,SgN,ainsuse nimetav,maja\r\n" +
+		"maja,1,noun,SgG,ainsuse omastav,\r\n" +
+		"maja,1,noun,SgP,ainsuse osastav,\r\n" +
+		"maja,1,noun,PlP,mitmuse osastav,\r\n"
+	if out.String() != want {
+		t.Errorf("got\n%q\nwant\n%q", out.String(), want)
+	}
+}
+
+func TestYAMLString(t *testing.T) {
+	tests := map[string]string{
+		"puu":        "puu",
+		"jõgi":       "jõgi",
+		"two words":  "two words",
+		"":           `""`,
+		"22":         `"22"`,
+		"1e3":        `"1e3"`,
+		"0x1F":       `"0x1F"`,
+		"no":         `"no"`,
+		"Null":       `"Null"`,
+		"- item":     `"- item"`,
+		"key: value": `"key: value"`,
+		"a #comment": `"a #comment"`,
+		" padded":    `" padded"`,
+		`say "hi"`:   `say "hi"`,
+		`"quoted"`:   `"\"quoted\""`,
+		"line\nfeed": `"line\nfeed"`,
+	}
+	for in, want := range tests {
+		if got := yamlString(in); got != want {
+			t.Errorf("yamlString(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestNewRenderer_UnknownFormat(t *testing.T) {
+	if _, err := NewRenderer(Config{Format: "xml"}); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+func TestTableRenderer_TSVIsUnquoted(t *testing.T) {
+	output := FormattedOutput{Word: "x", HomonymIndex: 1, PartOfSpeech: "noun", Lines: []FormLine{
+		{Code: "SgN", Label: "ainsuse nimetav", Values: []string{` "quoted"`, "tab\there", "new\nline"}},
+	}}
+	var buf bytes.Buffer
+	if err := (tableRenderer{comma: '\t'}).Render(&buf, output); err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+	want := "x\t1\tnoun\tSgN\tainsuse nimetav\t \"quoted\", tab here, new line\n"
+	if buf.String() != want {
+		t.Errorf("got  %q\nwant %q", buf.String(), want)
+	}
+}
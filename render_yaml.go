@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// yamlRenderer writes the JSONOutput schema as a YAML document. Each
+// document starts with "---", so batch output is a valid YAML stream.
+type yamlRenderer struct{}
+
+func (yamlRenderer) Render(w io.Writer, output FormattedOutput) error {
+	out := NewJSONOutput(output)
+
+	var sb strings.Builder
+	sb.WriteString("---\n")
+	fmt.Fprintf(&sb, "word: %s\n", yamlString(out.Word))
+	fmt.Fprintf(&sb, "homonym: %d\n", out.Homonym)
+	fmt.Fprintf(&sb, "homonyms: %d\n", out.Homonyms)
+	fmt.Fprintf(&sb, "part_of_speech: %s\n", yamlString(out.PartOfSpeech))
+	writeYAMLList(&sb, "", "inflection_types", out.InflectionTypes)
+	writeYAMLList(&sb, "", "translations", out.Translations)
+
+	if len(out.Lines) == 0 {
+		sb.WriteString("lines: []\n")
+	} else {
+		sb.WriteString("lines:\n")
+		for _, line := range out.Lines {
+			fmt.Fprintf(&sb, "  - code: %s\n", yamlString(line.Code))
+			fmt.Fprintf(&sb, "    label: %s\n", yamlString(line.Label))
+			writeYAMLList(&sb, "    ", "values", line.Values)
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// writeYAMLList writes key and a block sequence of values, or an empty
+// flow sequence if there are none.
+func writeYAMLList(sb *strings.Builder, indent, key string, values []string) {
+	if len(values) == 0 {
+		fmt.Fprintf(sb, "%s%s: []\n", indent, key)
+		return
+	}
+	fmt.Fprintf(sb, "%s%s:\n", indent, key)
+	for _, v := range values {
+		fmt.Fprintf(sb, "%s  - %s\n", indent, yamlString(v))
+	}
+}
+
+// yamlString returns s as a plain scalar when that reads back as the
+// same string, and double-quoted otherwise.
+func yamlString(s string) string {
+	if yamlPlainSafe(s) {
+		return s
+	}
+	// Go's escapes (\", \\, \n, \t, \xXX, \uXXXX, ...) are all valid in
+	// YAML double-quoted scalars
+	return strconv.Quote(s)
+}
+
+func yamlPlainSafe(s string) bool {
+	if s == "" || s != strings.TrimSpace(s) {
+		return false
+	}
+	// Indicator characters that start flow, block, anchor, tag or
+	// comment syntax, or a quoted scalar
+	if strings.ContainsRune("-?:,[]{}#&*!|>'\"%@`", rune(s[0])) {
+		return false
+	}
+	if strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.HasSuffix(s, ":") {
+		return false
+	}
+	for _, r := range s {
+		if unicode.IsControl(r) || r == '\uFEFF' {
+			return false
+		}
+	}
+	// Scalars that would be read as null, booleans or numbers
+	switch strings.ToLower(s) {
+	case "~", "null", "true", "false", "yes", "no", "on", "off", "y", "n", ".inf", "-.inf", ".nan":
+		return false
+	}
+	if _, err := strconv.ParseFloat(strings.ReplaceAll(s, "_", ""), 64); err == nil {
+		return false
+	}
+	if _, err := strconv.ParseInt(s, 0, 64); err == nil {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryPolicy controls how RetryingFetcher retries transient failures.
+type RetryPolicy struct {
+	MaxAttempts int           // Total attempts including the first; 1 or less disables retries
+	BaseDelay   time.Duration // Delay before the first retry; doubles on each further retry
+	MaxDelay    time.Duration // Upper bound for a single backoff delay
+
+	// MaxRetryAfter is the longest Retry-After the client waits for; if the
+	// server asks for more, the error is returned instead. Zero means no limit.
+	MaxRetryAfter time.Duration
+}
+
+// DefaultRetryPolicy is used by the CLI unless overridden by flags.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    10 * time.Second,
+
+	MaxRetryAfter: 30 * time.Second,
+}
+
+// RetryingFetcher wraps a Fetcher, retrying transient failures according
+// to its RetryPolicy. Placed outside a RateLimitedFetcher, every retry
+// waits for its own token.
+type RetryingFetcher struct {
+	upstream Fetcher
+	policy   RetryPolicy
+	sleep    func(ctx context.Context, d time.Duration) error
+}
+
+func NewRetryingFetcher(upstream Fetcher, policy RetryPolicy) *RetryingFetcher {
+	return &RetryingFetcher{
+		upstream: upstream,
+		policy:   policy,
+		sleep:    sleepContext,
+	}
+}
+
+func (f *RetryingFetcher) Search(ctx context.Context, word string) ([]byte, error) {
+	return f.do(ctx, func() ([]byte, error) { return f.upstream.Search(ctx, word) })
+}
+
+func (f *RetryingFetcher) WordDetails(ctx context.Context, wordID int64) ([]byte, error) {
+	return f.do(ctx, func() ([]byte, error) { return f.upstream.WordDetails(ctx, wordID) })
+}
+
+func (f *RetryingFetcher) ParadigmDetails(ctx context.Context, wordID int64) ([]byte, error) {
+	return f.do(ctx, func() ([]byte, error) { return f.upstream.ParadigmDetails(ctx, wordID) })
+}
+
+func (f *RetryingFetcher) do(ctx context.Context, fetch func() ([]byte, error)) ([]byte, error) {
+	for attempt := 1; ; attempt++ {
+		data, err := fetch()
+		if err == nil {
+			return data, nil
+		}
+		if attempt >= f.policy.MaxAttempts || !isRetryable(err) {
+			return nil, err
+		}
+
+		// Wait for the backoff delay, or longer if the server asked us to.
+		// Give up with this error rather than wait past the deadline or an
+		// unreasonable Retry-After, so callers see why the request failed.
+		delay := f.policy.backoff(attempt)
+		if after := retryAfter(err); after > delay {
+			if f.policy.MaxRetryAfter > 0 && after > f.policy.MaxRetryAfter {
+				return nil, err
+			}
+			delay = after
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return nil, err
+		}
+		if sleepErr := f.sleep(ctx, delay); sleepErr != nil {
+			return nil, sleepErr
+		}
+	}
+}
+
+// backoff returns the jittered delay before retry number n (1-based).
+func (p RetryPolicy) backoff(n int) time.Duration {
+	if p.BaseDelay <= 0 {
+		return 0
+	}
+	delay := p.BaseDelay
+	for i := 1; i < n && (p.MaxDelay <= 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	// Equal jitter: somewhere between half and the full delay
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+// isRetryable reports whether a failed request may succeed if repeated.
+// Only transient server conditions and network failures qualify; all API
+// calls are GETs, so repeating them is safe.
+func isRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var statusErr *APIError
+	if errors.As(err, &statusErr) {
+		switch statusErr.StatusCode {
+		case http.StatusRequestTimeout,
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	// Failed connections and responses cut off mid-body alike
+	var netErr *NetworkError
+	return errors.As(err, &netErr)
+}
+
+// retryAfter returns the delay requested by the server, if any.
+func retryAfter(err error) time.Duration {
+	var statusErr *APIError
+	if errors.As(err, &statusErr) {
+		return statusErr.RetryAfter
+	}
+	return 0
+}
+
+// parseRetryAfter parses a Retry-After header value given either as
+// delay-seconds or as an HTTP date. Returns 0 if absent or invalid.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// sleepContext waits for d or until ctx is done, whichever comes first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
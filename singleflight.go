@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"sync"
+)
+
+// flightGroup coalesces concurrent fetches for the same key so that only
+// one upstream call is in flight at a time; all callers share its result.
+// The zero value is ready to use.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+type flightCall struct {
+	done    chan struct{}
+	data    []byte
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// Do runs fn once for all concurrent callers with the same key.
+//
+// The shared call is not tied to any single caller's context: a caller
+// whose ctx ends stops waiting and gets ctx.Err(), and the call itself is
+// cancelled only once every caller has given up.
+func (g *flightGroup) Do(ctx context.Context, key string, fn func(context.Context) ([]byte, error)) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall)
+	}
+	c, ok := g.calls[key]
+	if !ok {
+		callCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		c = &flightCall{done: make(chan struct{}), cancel: cancel}
+		g.calls[key] = c
+		go g.run(callCtx, key, c, fn)
+	}
+	c.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.data, c.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		c.waiters--
+		if c.waiters == 0 {
+			// Nobody is waiting any more; abandon the call so a later
+			// caller starts afresh rather than inheriting a cancellation.
+			c.cancel()
+			g.forget(key, c)
+		}
+		g.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+func (g *flightGroup) run(ctx context.Context, key string, c *flightCall, fn func(context.Context) ([]byte, error)) {
+	c.data, c.err = fn(ctx)
+	c.cancel()
+
+	g.mu.Lock()
+	g.forget(key, c)
+	g.mu.Unlock()
+
+	close(c.done)
+}
+
+// forget removes c from the group if it is still the call for key.
+// g.mu must be held.
+func (g *flightGroup) forget(key string, c *flightCall) {
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+}
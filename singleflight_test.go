@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitForWaiters blocks until n callers are waiting on the call for key.
+func waitForWaiters(t *testing.T, g *flightGroup, key string, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		g.mu.Lock()
+		c := g.calls[key]
+		joined := c != nil && c.waiters == n
+		g.mu.Unlock()
+		if joined {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d callers on %q", n, key)
+}
+
+func TestFlightGroup_CoalescesConcurrentCalls(t *testing.T) {
+	var g flightGroup
+	var calls atomic.Int32
+	release := make(chan struct{})
+
+	fn := func(ctx context.Context) ([]byte, error) {
+		calls.Add(1)
+		<-release
+		return []byte("result"), nil
+	}
+
+	const callers = 10
+	var wg sync.WaitGroup
+	results := make([][]byte, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			data, err := g.Do(context.Background(), "search:puu", fn)
+			if err != nil {
+				t.Errorf("Do() error: %v", err)
+			}
+			results[i] = data
+		}(i)
+	}
+
+	waitForWaiters(t, &g, "search:puu", callers)
+	close(release)
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected 1 upstream call, got %d", n)
+	}
+	for i, data := range results {
+		if string(data) != "result" {
+			t.Errorf("caller %d got %q", i, data)
+		}
+	}
+}
+
+func TestFlightGroup_SharesErrors(t *testing.T) {
+	var g flightGroup
+	wantErr := errors.New("API error: 503 Service Unavailable")
+
+	_, err := g.Do(context.Background(), "k", func(ctx context.Context) ([]byte, error) {
+		return nil, wantErr
+	})
+	if !errors.Is(err, wantErr) {
+		t.Errorf("expected %v, got %v", wantErr, err)
+	}
+
+	// Errors are not remembered: the next call runs again
+	data, err := g.Do(context.Background(), "k", func(ctx context.Context) ([]byte, error) {
+		return []byte("ok"), nil
+	})
+	if err != nil || string(data) != "ok" {
+		t.Errorf("expected fresh call after error, got %q, %v", data, err)
+	}
+}
+
+func TestFlightGroup_CallerCancelDoesNotAffectOthers(t *testing.T) {
+	var g flightGroup
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var upstreamErr error
+
+	fn := func(ctx context.Context) ([]byte, error) {
+		close(started)
+		select {
+		case <-release:
+			return []byte("result"), nil
+		case <-ctx.Done():
+			upstreamErr = ctx.Err()
+			return nil, ctx.Err()
+		}
+	}
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	err1 := make(chan error, 1)
+	go func() {
+		_, err := g.Do(ctx1, "k", fn)
+		err1 <- err
+	}()
+	<-started
+
+	data2 := make(chan []byte, 1)
+	go func() {
+		data, _ := g.Do(context.Background(), "k", fn)
+		data2 <- data
+	}()
+	waitForWaiters(t, &g, "k", 2)
+
+	// First caller gives up; the second keeps waiting for the shared call
+	cancel1()
+	if err := <-err1; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected first caller to be cancelled, got %v", err)
+	}
+
+	close(release)
+	if data := <-data2; string(data) != "result" {
+		t.Errorf("expected second caller to get result, got %q", data)
+	}
+	if upstreamErr != nil {
+		t.Errorf("upstream call should not have been cancelled, got %v", upstreamErr)
+	}
+}
+
+func TestFlightGroup_AllCallersCancelAbandonsCall(t *testing.T) {
+	var g flightGroup
+	upstreamDone := make(chan error, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+
+	_, err := g.Do(ctx, "k", func(ctx context.Context) ([]byte, error) {
+		<-ctx.Done()
+		upstreamDone <- ctx.Err()
+		return nil, ctx.Err()
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected cancelled, got %v", err)
+	}
+
+	select {
+	case err := <-upstreamDone:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected upstream context cancelled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("upstream call was not cancelled after all callers left")
+	}
+
+	// A new caller starts a fresh call rather than inheriting the cancellation
+	data, err := g.Do(context.Background(), "k", func(ctx context.Context) ([]byte, error) {
+		return []byte("fresh"), nil
+	})
+	if err != nil || string(data) != "fresh" {
+		t.Errorf("expected fresh call, got %q, %v", data, err)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+)
+
+// TemplateData is what -template and -template-file templates render.
+// The fields of FormattedOutput are promoted, so {{.Word}} and
+// {{range .Lines}} work directly; Details holds the full API response.
+type TemplateData struct {
+	FormattedOutput
+	Details *WordDetails
+}
+
+// templateFuncs returns the helper functions available to templates.
+// Functions that look up forms use every form of the word, not just the
+// lines selected for display.
+func templateFuncs(data *TemplateData) template.FuncMap {
+	var forms map[string][]string
+	if data != nil {
+		forms = data.Forms
+	}
+	return template.FuncMap{
+		// form returns the forms for a morph code, e.g. {{form "SgG"}}
+		"form": func(code string) string {
+			return strings.Join(forms[code], ", ")
+		},
+		// label returns the Estonian name of a morph code
+		"label": GetMorphLabel,
+		// join joins a list, e.g. {{join .Translations "; "}}
+		"join": func(values []string, sep string) string {
+			return strings.Join(values, sep)
+		},
+		// translations returns the English translations, comma-separated
+		"translations": func() string {
+			if data == nil {
+				return ""
+			}
+			return strings.Join(data.Translations, ", ")
+		},
+	}
+}
+
+// parseTemplate parses a user template, so that syntax errors can be
+// reported before any lookup.
+func parseTemplate(text string, data *TemplateData) (*template.Template, error) {
+	tmpl, err := template.New("output").Option("missingkey=error").Funcs(templateFuncs(data)).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// renderTemplate executes a user template for one lookup result. A
+// trailing newline is added if the template does not end with one, so
+// batch results land on separate lines.
+func renderTemplate(w io.Writer, text string, data TemplateData) error {
+	// Parse per result: the helpers are bound to the data, and batch
+	// workers render concurrently
+	tmpl, err := parseTemplate(text, &data)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return fmt.Errorf("template: %w", err)
+	}
+	if buf.Len() > 0 && !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRun_Template(t *testing.T) {
+	tests := []struct {
+		name string
+		tmpl string
+		want string
+	}{
+		{"form", `{{.Word}}: {{form "SgG"}}`, "pank: panga\n"},
+		{"multi-value form", `{{form "PlP"}}`, "pankasid, panku\n"},
+		{"missing form", `[{{form "SgIll"}}]`, "[]\n"},
+		{"label", `{{label "SgG"}}`, "ainsuse omastav\n"},
+		{"translations", `{{.Word}} = {{translations}}`, "pank = bank, bench\n"},
+		{"join", `{{join .Translations " / "}}`, "bank / bench\n"},
+		{"homonym", `{{.Word}} {{.HomonymIndex}}/{{.HomonymCount}} #{{.WordID}}`, "pank 2/2 #2\n"},
+		{"lines", "{{range .Lines}}{{.Code}}={{join .Values \"|\"}}\n{{end}}", "SgN=pank\nSgG=panga\nSgP=panka\nPlP=pankasid|panku\n"},
+		{"details", `{{range .Details.Lexemes}}{{range .Pos}}{{.Code}}{{end}}{{end}}`, "s\n"},
+		{"keeps trailing newline", "{{.Word}}\n", "pank\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{Homonym: 2, Template: tt.tmpl}
+			var buf bytes.Buffer
+			if err := run(context.Background(), "pank", cfg, pankFetcher(), &buf); err != nil {
+				t.Fatalf("run() error: %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("got %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderTemplate_FormUsesAllForms(t *testing.T) {
+	details := &WordDetails{Paradigms: []Paradigm{{InflectionTypeNr: "26", Forms: []Form{
+		{Value: "puu", MorphCode: "SgN"},
+		{Value: "puusse", MorphCode: "SgIll"},
+		{Value: "puhu", MorphCode: "SgIll"},
+	}}}}
+	data := TemplateData{FormattedOutput: FormatOutput("puu", details, 1, 1, false), Details: details}
+
+	var buf bytes.Buffer
+	if err := renderTemplate(&buf, `{{form "SgIll"}}`, data); err != nil {
+		t.Fatalf("renderTemplate() error: %v", err)
+	}
+	if buf.String() != "puusse, puhu\n" {
+		t.Errorf("got %q", buf.String())
+	}
+}
+
+func TestParseTemplate_Errors(t *testing.T) {
+	for _, tmpl := range []string{`{{.Word`, `{{nosuchfunc}}`} {
+		if _, err := parseTemplate(tmpl, nil); err == nil || !strings.Contains(err.Error(), "invalid template") {
+			t.Errorf("parseTemplate(%q) error = %v, want invalid template", tmpl, err)
+		}
+	}
+}
+
+func TestRunBatch_Template(t *testing.T) {
+	fetcher := NewDictFetcher("puu", "maja")
+	cfg := Config{Homonym: 1, Format: formatCSV, Template: `{{.Word}}: {{form "SgN"}}`}
+	var out, errOut bytes.Buffer
+	runBatch(context.Background(), []string{"puu", "maja"}, cfg, fetcher, &out, &errOut)
+
+	if out.String() != "puu: puu\nmaja: maja\n" {
+		t.Errorf("got %q", out.String())
+	}
+}
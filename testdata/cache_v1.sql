@@ -0,0 +1,7 @@
+-- Cache schema version 1: the original key/value table, before created_at.
+CREATE TABLE cache (
+	key   TEXT PRIMARY KEY,
+	value BLOB
+);
+INSERT INTO cache VALUES ('search:puu', '{"words":[{"wordId":1,"wordValue":"puu","lang":"est"}]}');
+INSERT INTO cache VALUES ('details:1', '{"wordClass":"noun"}');
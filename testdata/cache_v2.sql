@@ -0,0 +1,8 @@
+-- Cache schema version 2: created_at added; user_version not yet in use.
+CREATE TABLE cache (
+	key        TEXT PRIMARY KEY,
+	value      BLOB,
+	created_at INTEGER
+);
+INSERT INTO cache VALUES ('search:puu', '{"words":[{"wordId":1,"wordValue":"puu","lang":"est"}]}', 1700000000);
+INSERT INTO cache VALUES ('details:1', '{"wordClass":"noun"}', 1700000100);
@@ -0,0 +1,11 @@
+-- Cache schema version 3: accessed_at added for LRU eviction; user_version
+-- not yet in use.
+CREATE TABLE cache (
+	key         TEXT PRIMARY KEY,
+	value       BLOB,
+	created_at  INTEGER,
+	accessed_at INTEGER
+);
+CREATE INDEX cache_accessed_at ON cache (accessed_at);
+INSERT INTO cache VALUES ('search:puu', '{"words":[{"wordId":1,"wordValue":"puu","lang":"est"}]}', 1700000000, 1700000500);
+INSERT INTO cache VALUES ('details:1', '{"wordClass":"noun"}', 1700000100, 1700000100);
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// warmWord fetches the search result and the details and paradigms of
+// every Estonian homonym of word, so that a caching fetcher can later
+// answer any lookup of it offline. It returns the number of homonyms.
+func warmWord(ctx context.Context, word string, fetcher Fetcher) (int, error) {
+	searchData, err := fetcher.Search(ctx, word)
+	if err != nil {
+		return 0, err
+	}
+
+	searchResult, err := ParseSearchResult(searchData)
+	if err != nil {
+		return 0, err
+	}
+
+	estWords := FilterEstonianWords(searchResult.Words)
+	if len(estWords) == 0 {
+		return 0, fmt.Errorf("%w: %s", ErrWordNotFound, word)
+	}
+
+	for _, w := range estWords {
+		if _, _, err := fetchWordData(ctx, fetcher, w.WordID); err != nil {
+			return 0, err
+		}
+	}
+	return len(estWords), nil
+}
+
+// runWarm warms the cache for each word using up to cfg.Jobs concurrent
+// workers, reporting progress to w as words complete. Like runBatch it
+// keeps going after per-word errors and stops early only if ctx is cancelled.
+func runWarm(ctx context.Context, words []string, cfg Config, fetcher Fetcher, w io.Writer) BatchSummary {
+	jobs := cfg.Jobs
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	var (
+		mu      sync.Mutex
+		summary BatchSummary
+	)
+	report := func(word string, homonyms int, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if errors.Is(err, context.Canceled) {
+			return
+		}
+
+		summary.add(word, err)
+		var status string
+		switch {
+		case err == nil:
+			status = fmt.Sprintf("cached %d %s", homonyms, plural(int64(homonyms), "homonym", "homonyms"))
+		case isNotFound(err):
+			status = "not found"
+		default:
+			status = fmt.Sprintf("error: %v", err)
+		}
+		_, _ = fmt.Fprintf(w, "[%d/%d] %s: %s\n", summary.Total(), len(words), word, status)
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for n := 0; n < jobs; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if ctx.Err() != nil {
+					continue
+				}
+				homonyms, err := warmWord(ctx, words[i], fetcher)
+				report(words[i], homonyms, err)
+			}
+		}()
+	}
+
+feed:
+	for i := range words {
+		// select picks at random when both cases are ready
+		if ctx.Err() != nil {
+			break
+		}
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	return summary
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestWarmWord_FetchesEveryEstonianHomonym(t *testing.T) {
+	mock := &MockFetcher{
+		SearchResponse: []byte(`{"words":[
+			{"wordId":1,"wordValue":"pank","lang":"est"},
+			{"wordId":2,"wordValue":"pank","lang":"eng"},
+			{"wordId":3,"wordValue":"pank","lang":"est"}
+		]}`),
+		DetailsResponse:  []byte(`{"wordClass":"noun"}`),
+		ParadigmResponse: []byte(`[]`),
+	}
+
+	homonyms, err := warmWord(context.Background(), "pank", mock)
+	if err != nil {
+		t.Fatalf("warmWord() error: %v", err)
+	}
+	if homonyms != 2 {
+		t.Errorf("homonyms = %d, want 2", homonyms)
+	}
+	if fmt.Sprint(mock.DetailsCalls) != "[1 3]" {
+		t.Errorf("DetailsCalls = %v, want [1 3]", mock.DetailsCalls)
+	}
+	if fmt.Sprint(mock.ParadigmCalls) != "[1 3]" {
+		t.Errorf("ParadigmCalls = %v, want [1 3]", mock.ParadigmCalls)
+	}
+}
+
+func TestRunWarm_ReportsProgressAndFailures(t *testing.T) {
+	dict := NewDictFetcher("puu", "maja")
+	dict.Failing["kass"] = true
+
+	var progress bytes.Buffer
+	summary := runWarm(context.Background(), []string{"puu", "xyzzy", "kass", "maja"}, Config{}, dict, &progress)
+
+	want := "[1/4] puu: cached 1 homonym\n" +
+		"[2/4] xyzzy: not found\n" +
+		"[3/4] kass: error: API error: 502 Bad Gateway\n" +
+		"[4/4] maja: cached 1 homonym\n"
+	if progress.String() != want {
+		t.Errorf("progress =\n%s\nwant\n%s", progress.String(), want)
+	}
+	if strings.Join(summary.Found, ",") != "puu,maja" {
+		t.Errorf("Found = %v", summary.Found)
+	}
+	if strings.Join(summary.NotFound, ",") != "xyzzy" {
+		t.Errorf("NotFound = %v", summary.NotFound)
+	}
+	if strings.Join(summary.Failed, ",") != "kass" {
+		t.Errorf("Failed = %v", summary.Failed)
+	}
+	if summary.ExitCode() != 3 {
+		t.Errorf("ExitCode() = %d, want 3", summary.ExitCode())
+	}
+}
+
+func TestRunWarm_LookupsWorkOfflineAfterwards(t *testing.T) {
+	cache := openTestCache(t)
+	words := []string{"puu", "maja", "kass", "koer"}
+	dict := NewDictFetcher(words...)
+
+	online := NewCachingFetcher(dict, cache, DefaultCacheOptions)
+	summary := runWarm(context.Background(), words, Config{Jobs: 3}, online, &bytes.Buffer{})
+	if len(summary.Found) != len(words) {
+		t.Fatalf("Found = %v, want all of %v", summary.Found, words)
+	}
+
+	opts := DefaultCacheOptions
+	opts.Offline = true
+	offline := NewCachingFetcher(&MockFetcher{}, cache, opts)
+	for _, word := range words {
+		var out bytes.Buffer
+		if err := run(context.Background(), word, Config{Homonym: 1, All: true}, offline, &out); err != nil {
+			t.Errorf("offline lookup of %s after warm: %v", word, err)
+			continue
+		}
+		if !strings.Contains(out.String(), word) {
+			t.Errorf("offline lookup of %s = %q", word, out.String())
+		}
+	}
+}
+
+func TestRunWarm_StopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	dict := NewDictFetcher("puu", "maja")
+	summary := runWarm(ctx, []string{"puu", "maja"}, Config{}, dict, &bytes.Buffer{})
+
+	if summary.Total() != 0 {
+		t.Errorf("Total() = %d after cancellation, want 0", summary.Total())
+	}
+	if dict.Calls != 0 {
+		t.Errorf("expected no upstream calls, got %d", dict.Calls)
+	}
+}